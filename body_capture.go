@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const truncatedBodySuffix = "..."
+
+// jsonMemberPattern matches "key": value pairs of JSON objects, value could be cut by truncation.
+var jsonMemberPattern = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"(\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]*)`)
+
+//nolint:gochecknoglobals
+var defaultBodyContentTypes = []string{
+	"application/json", "application/*+json", "application/xml", "application/x-www-form-urlencoded", "text/*",
+}
+
+// bodyBuffer keeps first limit bytes written to it. The transport could write the request body
+// after the response was returned, so the buffer is guarded by mu.
+type bodyBuffer struct {
+	mu        sync.Mutex
+	buf       []byte
+	limit     int
+	truncated bool
+}
+
+func (b *bodyBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	room := b.limit - len(b.buf)
+	if len(p) > room {
+		b.truncated = true
+	} else {
+		room = len(p)
+	}
+	if room > 0 {
+		b.buf = append(b.buf, p[:room]...)
+	}
+	return len(p), nil
+}
+
+// captured returns written bytes and whether there were more bytes than the limit.
+func (b *bodyBuffer) captured() (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return string(b.buf), b.truncated
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// teeRequestBody replaces body of the request with a reader copying first limit bytes to the returned buffer.
+func teeRequestBody(req *http.Request, limit int) *bodyBuffer {
+	buf := &bodyBuffer{limit: limit}
+	req.Body = readCloser{io.TeeReader(req.Body, buf), req.Body}
+	return buf
+}
+
+// responseBody wraps the response body to call done once the caller reads it to the end, gets a read error
+// or closes it. Done gets the number of bytes read and whether the body was read to EOF. If capture is set,
+// bytes read by the caller are copied to it, so RoundTrip isn't blocked by reading the body itself.
+type responseBody struct {
+	io.ReadCloser
+
+	capture  *bodyBuffer
+	read     int64
+	complete bool
+	done     func(read int64, complete bool)
+	once     sync.Once
+}
+
+// wrapResponseBody replaces body of the response with responseBody.
+func wrapResponseBody(resp *http.Response, capture *bodyBuffer, done func(read int64, complete bool)) {
+	resp.Body = &responseBody{ReadCloser: resp.Body, capture: capture, done: done}
+}
+
+func (r *responseBody) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	if n > 0 && r.capture != nil {
+		_, _ = r.capture.Write(p[:n])
+	}
+	if err != nil {
+		r.complete = errors.Is(err, io.EOF)
+		r.finish()
+	}
+	return n, err //nolint:wrapcheck
+}
+
+func (r *responseBody) Close() error {
+	err := r.ReadCloser.Close()
+	r.finish()
+	return err //nolint:wrapcheck
+}
+
+func (r *responseBody) finish() {
+	r.once.Do(func() {
+		r.done(r.read, r.complete)
+	})
+}
+
+// matchContentType reports whether media type of the header matches any of patterns (e.g. "text/*").
+func matchContentType(header http.Header, patterns []string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return "", false
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), mediaType); ok {
+			return mediaType, true
+		}
+	}
+	return "", false
+}
+
+// scrubBody replaces sensitive data in the body and marks truncated body with a suffix.
+func scrubBody(scrubber *Scrubber, mediaType, body string, truncated bool) string {
+	body = scrubBodyText(scrubber, mediaType, body)
+	if truncated {
+		body += truncatedBodySuffix
+	}
+	return body
+}
+
+// scrubBodyText replaces sensitive data in the body. JSON bodies are scrubbed as parsed values if possible,
+// truncated JSON bodies have values of sensitive keys replaced in the text. Other bodies are matched against patterns.
+func scrubBodyText(scrubber *Scrubber, mediaType, body string) string {
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return scrubber.ScrubQuery(body)
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var generic interface{}
+		if json.Unmarshal([]byte(body), &generic) == nil {
+			if data, err := json.Marshal(scrubber.ScrubValue("", generic)); err == nil {
+				return string(data)
+			}
+		}
+		// body is truncated or invalid, so values of sensitive keys are replaced in the text
+		body = jsonMemberPattern.ReplaceAllStringFunc(body, func(member string) string {
+			m := jsonMemberPattern.FindStringSubmatch(member)
+			if !scrubber.IsSensitiveKey(m[1]) {
+				return member
+			}
+			return `"` + m[1] + `"` + m[2] + `"` + scrubber.Placeholder + `"`
+		})
+	}
+	return scrubber.ScrubString(body)
+}
@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyBuffer(t *testing.T) {
+	t.Parallel()
+
+	buf := &bodyBuffer{limit: 5}
+	n, err := buf.Write([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	body, truncated := buf.captured()
+	assert.Equal(t, "abc", body)
+	assert.False(t, truncated)
+
+	n, err = buf.Write([]byte("defgh"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n, "all bytes should be reported as written")
+	body, truncated = buf.captured()
+	assert.Equal(t, "abcde", body)
+	assert.True(t, truncated)
+}
+
+func TestResponseBody(t *testing.T) {
+	t.Parallel()
+
+	t.Run("read to the end", func(t *testing.T) {
+		t.Parallel()
+
+		capture := &bodyBuffer{limit: 4}
+		var calls int
+		resp := &http.Response{Body: io.NopCloser(strings.NewReader("long response body"))}
+		wrapResponseBody(resp, capture, func(int64, bool) { calls++ })
+		assert.Equal(t, 0, calls, "done should not be called before the body is read")
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "long response body", string(body))
+		assert.Equal(t, 1, calls)
+
+		captured, truncated := capture.captured()
+		assert.Equal(t, "long", captured)
+		assert.True(t, truncated)
+
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, 1, calls, "done should be called once")
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		t.Parallel()
+
+		capture := &bodyBuffer{limit: 10}
+		var calls int
+		resp := &http.Response{Body: io.NopCloser(strings.NewReader("short body"))}
+		wrapResponseBody(resp, capture, func(int64, bool) { calls++ })
+
+		buf := make([]byte, 5)
+		_, err := io.ReadFull(resp.Body, buf)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, 1, calls)
+
+		captured, _ := capture.captured()
+		assert.Equal(t, "short", captured, "only bytes read by the caller should be captured")
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		t.Parallel()
+
+		readErr := errors.New("connection reset")
+		capture := &bodyBuffer{limit: 10}
+		var calls int
+		resp := &http.Response{Body: io.NopCloser(io.MultiReader(strings.NewReader("part"), iotest.ErrReader(readErr)))}
+		wrapResponseBody(resp, capture, func(int64, bool) { calls++ })
+
+		body, err := io.ReadAll(resp.Body)
+		assert.ErrorIs(t, err, readErr)
+		assert.Equal(t, "part", string(body))
+		assert.Equal(t, 1, calls)
+
+		captured, _ := capture.captured()
+		assert.Equal(t, "part", captured)
+	})
+}
+
+func TestMatchContentType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		contentType string
+		mediaType   string
+		ok          bool
+	}{
+		{"application/json", "application/json", true},
+		{"application/problem+json; charset=utf-8", "application/problem+json", true},
+		{"Text/Plain", "text/plain", true},
+		{"image/png", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		header.Set("Content-Type", tt.contentType)
+		mediaType, ok := matchContentType(header, defaultBodyContentTypes)
+		assert.Equal(t, tt.ok, ok, tt.contentType)
+		assert.Equal(t, tt.mediaType, mediaType, tt.contentType)
+	}
+}
+
+func TestScrubBody(t *testing.T) {
+	t.Parallel()
+
+	scrubber := NewScrubber()
+
+	assert.JSONEq(t,
+		`{"user":"john","password":"[Filtered]","contact":"[Filtered]"}`,
+		scrubBody(scrubber, "application/json", `{"user":"john","password":"hunter2","contact":"john@example.com"}`, false),
+	)
+	assert.Equal(t,
+		`{"password":"[Filtered]","contact":"[Filtered]", "token": "[Filtered]"...`,
+		scrubBody(scrubber, "application/json", `{"password":"hunter2","contact":"john@example.com", "token": "abc`, true),
+		"truncated JSON should be scrubbed",
+	)
+	assert.Equal(t,
+		"user=john&password=[Filtered]",
+		scrubBody(scrubber, "application/x-www-form-urlencoded", "user=john&password=hunter2", false),
+	)
+	assert.Equal(t, "mail [Filtered] failed", scrubBody(scrubber, "text/plain", "mail john@example.com failed", false))
+}
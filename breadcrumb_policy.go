@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"math/rand"
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type hostSampleRate struct {
+	pattern string
+	rate    float64
+}
+
+// BreadcrumbStatusLevel will set a level of breadcrumbs for responses with provided status class
+// (e.g. 5 for 5xx responses). Level passed to NewBreadcrumbTransport is used for classes without explicitly set level.
+func BreadcrumbStatusLevel(statusClass int, level sentry.Level) BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.StatusLevels[statusClass] = level
+	}
+}
+
+// BreadcrumbErrorLevel will set a level of breadcrumbs for requests failed without a response.
+func BreadcrumbErrorLevel(level sentry.Level) BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.ErrorLevel = level
+	}
+}
+
+// BreadcrumbHostSampleRate will limit breadcrumbs for requests to hosts matching provided glob pattern
+// (e.g. "metrics.*") to a random share of requests, 0 disables them. The first matching pattern is used.
+// Spans are still recorded for all requests. Panics if the pattern is invalid.
+func BreadcrumbHostSampleRate(pattern string, rate float64) BreadcrumbTransportOption {
+	mustValidHostPattern(pattern)
+	return func(b *breadcrumbTransport) {
+		b.HostSampleRates = append(b.HostSampleRates, hostSampleRate{pattern, rate})
+	}
+}
+
+// BreadcrumbLevelFunc will set a function returning a level of the breadcrumb for the request,
+// resp is nil if the request failed. Breadcrumb is not added if the function returns false.
+// It takes precedence over status and error levels, host sample rates are applied before it.
+func BreadcrumbLevelFunc(
+	levelFunc func(req *http.Request, resp *http.Response, err error) (sentry.Level, bool),
+) BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.LevelFunc = levelFunc
+	}
+}
+
+// breadcrumbLevel returns level of the breadcrumb for the request and whether the breadcrumb should be added.
+func (b breadcrumbTransport) breadcrumbLevel(req *http.Request, resp *http.Response, err error) (sentry.Level, bool) {
+	for _, sampleRate := range b.HostSampleRates {
+		if matchHost(sampleRate.pattern, req.URL) {
+			if rand.Float64() >= sampleRate.rate { //nolint:gosec
+				return "", false
+			}
+			break
+		}
+	}
+
+	if b.LevelFunc != nil {
+		return b.LevelFunc(req, resp, err)
+	}
+	if err != nil {
+		if b.ErrorLevel != "" {
+			return b.ErrorLevel, true
+		}
+		return b.Level, true
+	}
+	if level, ok := b.StatusLevels[resp.StatusCode/100]; ok {
+		return level, true
+	}
+	return b.Level, true
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreadcrumbLevel(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("connection refused")
+	policy := []BreadcrumbTransportOption{
+		BreadcrumbStatusLevel(4, sentry.LevelWarning),
+		BreadcrumbStatusLevel(5, sentry.LevelError),
+		BreadcrumbErrorLevel(sentry.LevelError),
+		BreadcrumbHostSampleRate("metrics.*", 0),
+		BreadcrumbHostSampleRate("*.example.com", 1),
+	}
+
+	tests := []struct {
+		name    string
+		options []BreadcrumbTransportOption
+		url     string
+		status  int
+		err     error
+		level   sentry.Level
+		ok      bool
+	}{
+		{"default level", nil, "https://api.example.com/", http.StatusInternalServerError, nil, sentry.LevelInfo, true},
+		{"default error level", nil, "https://api.example.com/", 0, errFailed, sentry.LevelInfo, true},
+		{"status without level", policy, "https://api.example.com/", http.StatusOK, nil, sentry.LevelInfo, true},
+		{"4xx", policy, "https://api.example.com/", http.StatusNotFound, nil, sentry.LevelWarning, true},
+		{"5xx", policy, "https://api.example.com/", http.StatusBadGateway, nil, sentry.LevelError, true},
+		{"error", policy, "https://api.example.com/", 0, errFailed, sentry.LevelError, true},
+		{"filtered host", policy, "http://metrics.local:9090/push", http.StatusBadGateway, nil, "", false},
+		{
+			"level func",
+			append(policy, BreadcrumbLevelFunc(func(req *http.Request, resp *http.Response, _ error) (sentry.Level, bool) {
+				if req.URL.Path == "/health" {
+					return "", false
+				}
+				return sentry.LevelDebug, resp.StatusCode != http.StatusNotModified
+			})),
+			"https://api.example.com/", http.StatusBadGateway, nil, sentry.LevelDebug, true,
+		},
+		{
+			"level func skip",
+			[]BreadcrumbTransportOption{BreadcrumbLevelFunc(func(*http.Request, *http.Response, error) (sentry.Level, bool) {
+				return "", false
+			})},
+			"https://api.example.com/", http.StatusOK, nil, "", false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := NewBreadcrumbTransport(sentry.LevelInfo, nil, tt.options...).(*breadcrumbTransport)
+			var resp *http.Response
+			if tt.err == nil {
+				resp = &http.Response{StatusCode: tt.status}
+			}
+			level, ok := transport.breadcrumbLevel(httptest.NewRequest(http.MethodGet, tt.url, nil), resp, tt.err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.level, level)
+		})
+	}
+
+	assert.Panics(t, func() { BreadcrumbHostSampleRate("[", 0) })
+}
+
+func TestBreadcrumbHostSampleRate(t *testing.T) {
+	t.Parallel()
+
+	transport := NewBreadcrumbTransport(sentry.LevelInfo, nil, BreadcrumbHostSampleRate("metrics.*", 0.5)).(*breadcrumbTransport)
+	req := httptest.NewRequest(http.MethodGet, "http://metrics.local/push", nil)
+
+	var added int
+	for i := 0; i < 1000; i++ {
+		if _, ok := transport.breadcrumbLevel(req, &http.Response{StatusCode: http.StatusOK}, nil); ok {
+			added++
+		}
+	}
+	assert.InDelta(t, 500, added, 100)
+}
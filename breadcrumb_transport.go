@@ -1,50 +1,254 @@
 package logger
 
 import (
+	"fmt"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap/zapcore"
 )
 
+const defaultTransportSpanOp = "http.client"
+
 type breadcrumbTransport struct {
 	Transport http.RoundTripper
 
-	Level sentry.Level
+	Level           sentry.Level
+	StatusLevels    map[int]sentry.Level
+	ErrorLevel      sentry.Level
+	HostSampleRates []hostSampleRate
+	LevelFunc       func(req *http.Request, resp *http.Response, err error) (sentry.Level, bool)
+
+	RequestIDHeader string
+
+	Scrubber *Scrubber
+
+	Propagators []Propagator
+
+	PropagationTargets []func(req *http.Request) bool
+
+	RecordTimings bool
+
+	RequestBodyLimit  int
+	ResponseBodyLimit int
+	BodyContentTypes  []string
+	BodyScrubber      *Scrubber
+
+	Log           bool
+	LogLevels     map[int]zapcore.Level
+	ErrorLogLevel zapcore.Level
+
+	SpanName func(req *http.Request) (op, description string)
 }
 
-func NewBreadcrumbTransport(level sentry.Level, transport http.RoundTripper) http.RoundTripper {
+type BreadcrumbTransportOption func(*breadcrumbTransport)
+
+// ForwardRequestIDHeader will set a name of the header used to forward request id from the request context
+// to outgoing requests. Empty name disables forwarding. Request id is forwarded only to propagation targets.
+func ForwardRequestIDHeader(name string) BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.RequestIDHeader = name
+	}
+}
+
+// TransportScrubber will set Scrubber to replace sensitive query parameters in URLs of spans and breadcrumbs.
+func TransportScrubber(scrubber *Scrubber) BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.Scrubber = scrubber
+	}
+}
+
+// TransportTracePropagators will set propagators used to add trace context to outgoing requests.
+// By default sentry-trace, W3C traceparent and baggage headers are added. No propagators disables propagation.
+func TransportTracePropagators(propagators ...Propagator) BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.Propagators = propagators
+	}
+}
+
+// TransportTimings will enable recording of DNS lookup, connect, TLS handshake, time to first byte and
+// connection reuse with httptrace. Timings are added to breadcrumb data and as child spans of the request span.
+func TransportTimings() BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.RecordTimings = true
+	}
+}
+
+// CaptureRequestBody will add up to limit bytes of the request body to the breadcrumb data
+// if the request failed or the response status is not 2xx. Body is read by the underlying transport as usual.
+func CaptureRequestBody(limit int) BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.RequestBodyLimit = limit
+	}
+}
+
+// CaptureResponseBody will record up to limit bytes of the response body if the response status is not 2xx.
+// The body is captured while the caller reads it, so it's added as a second breadcrumb with the same url, method
+// and status code when the body is read to the end or closed. Breadcrumb of the request is added right away.
+func CaptureResponseBody(limit int) BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.ResponseBodyLimit = limit
+	}
+}
+
+// CaptureBodyContentTypes will set media type patterns (e.g. "text/*") of bodies captured with
+// CaptureRequestBody and CaptureResponseBody. By default JSON, XML, form and text bodies are captured.
+func CaptureBodyContentTypes(patterns ...string) BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.BodyContentTypes = patterns
+	}
+}
+
+// TransportSpanName will set a function returning op and description of the span for the request.
+// Empty values are replaced with defaults: "http.client" op and "METHOD scheme://host/path" description.
+func TransportSpanName(spanName func(req *http.Request) (op, description string)) BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.SpanName = spanName
+	}
+}
+
+// TransportPropagationTargets will limit trace and request id propagation to requests to hosts matching any of
+// provided glob patterns (e.g. "*.example.com"). Patterns with a port are matched against host with port.
+// Spans and breadcrumbs are still recorded for all requests. Panics if any of patterns is invalid.
+func TransportPropagationTargets(patterns ...string) BreadcrumbTransportOption {
+	for _, pattern := range patterns {
+		mustValidHostPattern(pattern)
+	}
+	return TransportPropagationTargetFunc(func(req *http.Request) bool {
+		for _, pattern := range patterns {
+			if matchHost(pattern, req.URL) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// TransportPropagationTargetRegexps will limit trace and request id propagation to requests with URLs matching
+// any of provided regular expressions. Spans and breadcrumbs are still recorded for all requests.
+func TransportPropagationTargetRegexps(regexps ...*regexp.Regexp) BreadcrumbTransportOption {
+	return TransportPropagationTargetFunc(func(req *http.Request) bool {
+		rawURL := req.URL.String()
+		for _, re := range regexps {
+			if re.MatchString(rawURL) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// TransportPropagationTargetFunc will limit trace and request id propagation to requests matching provided predicate.
+// Spans and breadcrumbs are still recorded for all requests. If several targets are set, trace is propagated
+// to requests matching any of them.
+func TransportPropagationTargetFunc(target func(req *http.Request) bool) BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.PropagationTargets = append(b.PropagationTargets, target)
+	}
+}
+
+func NewBreadcrumbTransport(
+	level sentry.Level, transport http.RoundTripper, options ...BreadcrumbTransportOption,
+) http.RoundTripper {
 	if transport == nil {
 		transport = http.DefaultTransport
 	}
-	return &breadcrumbTransport{
-		Transport: transport,
-		Level:     level,
+	breadcrumbTransport := &breadcrumbTransport{
+		Transport:        transport,
+		Level:            level,
+		StatusLevels:     make(map[int]sentry.Level),
+		RequestIDHeader:  defaultRequestIDHeader,
+		Propagators:      defaultPropagators,
+		BodyContentTypes: defaultBodyContentTypes,
+		LogLevels:        make(map[int]zapcore.Level, len(defaultTransportLogLevels)),
+		ErrorLogLevel:    zapcore.ErrorLevel,
+	}
+	for statusClass, logLevel := range defaultTransportLogLevels {
+		breadcrumbTransport.LogLevels[statusClass] = logLevel
+	}
+
+	for _, option := range options {
+		option(breadcrumbTransport)
 	}
+
+	// captured bodies are always scrubbed, even if URLs are not
+	breadcrumbTransport.BodyScrubber = breadcrumbTransport.Scrubber
+	if breadcrumbTransport.BodyScrubber == nil {
+		breadcrumbTransport.BodyScrubber = NewScrubber()
+	}
+
+	return breadcrumbTransport
 }
 
 //nolint:contextcheck
 func (b breadcrumbTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	span := sentry.StartSpan(req.Context(), req.URL.String(), sentry.ContinueFromRequest(req))
+	url := req.URL.String()
+	if b.Scrubber != nil {
+		url = b.Scrubber.ScrubURL(url)
+	}
+
+	op, description := b.spanName(req)
+	span := sentry.StartSpan(req.Context(), op, continueFromHeaders(b.Propagators, req.Header))
+	span.Description = description
+	setRequestSpanData(span, req, b.Scrubber)
 	defer span.Finish()
 
-	//nolint:canonicalheader
-	req.Header.Add("sentry-trace", span.ToSentryTrace())
+	if b.propagateTo(req) {
+		injectHeaders(b.Propagators, span, req.Header)
+
+		if requestID := RequestID(req.Context()); requestID != "" && b.RequestIDHeader != "" &&
+			req.Header.Get(b.RequestIDHeader) == "" {
+			req.Header.Set(b.RequestIDHeader, requestID)
+		}
+	}
 
 	breadcrumb := sentry.Breadcrumb{
 		Data: map[string]interface{}{
-			BreadcrumbDataURL:    req.URL.String(),
+			BreadcrumbDataURL:    url,
 			BreadcrumbDataMethod: req.Method,
 		},
-		Level:     b.Level,
 		Timestamp: time.Now().UTC(),
 		Type:      BreadcrumbTypeHTTP,
 	}
 
-	resp, err := b.Transport.RoundTrip(req.WithContext(span.Context()))
+	ctx := span.Context()
+	var timings *httpTimings
+	if b.RecordTimings {
+		timings = &httpTimings{}
+		ctx = httptrace.WithClientTrace(ctx, timings.clientTrace())
+	}
+	outReq := req.WithContext(ctx)
+
+	var requestBody *bodyBuffer
+	var requestMediaType string
+	if b.RequestBodyLimit > 0 && req.Body != nil && req.Body != http.NoBody {
+		if mediaType, ok := matchContentType(req.Header, b.BodyContentTypes); ok {
+			requestMediaType = mediaType
+			requestBody = teeRequestBody(outReq, b.RequestBodyLimit)
+		}
+	}
+
+	start := time.Now()
+	resp, err := b.Transport.RoundTrip(outReq)
+	duration := time.Since(start)
+
+	if timings != nil {
+		timings.record(span, breadcrumb.Data)
+	}
 
 	if err == nil {
 		span.Status = SpanStatus(resp.StatusCode)
+		span.SetData("http.response.status_code", resp.StatusCode)
+		if resp.ContentLength >= 0 {
+			span.SetData("http.response_content_length", resp.ContentLength)
+		}
 		breadcrumb.Data[BreadcrumbDataStatusCode] = resp.StatusCode
 		breadcrumb.Data[BreadcrumbDataReason] = resp.Status
 	} else {
@@ -52,7 +256,134 @@ func (b breadcrumbTransport) RoundTrip(req *http.Request) (*http.Response, error
 		breadcrumb.Message = err.Error()
 	}
 
-	Hub(span.Context()).AddBreadcrumb(&breadcrumb, nil)
+	level, addBreadcrumb := b.breadcrumbLevel(req, resp, err)
+	breadcrumb.Level = level
+
+	hub := Hub(span.Context())
+	var responseBody *bodyBuffer
+	var onBodyDone []func(read int64, complete bool)
+	if addBreadcrumb && (err != nil || resp.StatusCode/100 != 2) {
+		if requestBody != nil {
+			body, truncated := requestBody.captured()
+			breadcrumb.Data[BreadcrumbDataRequestBody] = scrubBody(b.BodyScrubber, requestMediaType, body, truncated)
+		}
+		if err == nil && b.ResponseBodyLimit > 0 && resp.StatusCode != http.StatusSwitchingProtocols {
+			if mediaType, ok := matchContentType(resp.Header, b.BodyContentTypes); ok {
+				// the body is captured while the caller reads it, so it's recorded as a separate breadcrumb
+				// when the caller is done with it
+				responseBody = &bodyBuffer{limit: b.ResponseBodyLimit}
+				onBodyDone = append(onBodyDone, func(int64, bool) {
+					body, truncated := responseBody.captured()
+					if body == "" {
+						return
+					}
+					hub.AddBreadcrumb(&sentry.Breadcrumb{
+						Data: map[string]interface{}{
+							BreadcrumbDataURL:          url,
+							BreadcrumbDataMethod:       req.Method,
+							BreadcrumbDataStatusCode:   resp.StatusCode,
+							BreadcrumbDataResponseBody: scrubBody(b.BodyScrubber, mediaType, body, truncated),
+						},
+						Level:     level,
+						Timestamp: time.Now().UTC(),
+						Type:      BreadcrumbTypeHTTP,
+					}, nil)
+				})
+			}
+		}
+	}
+
+	if addBreadcrumb {
+		hub.AddBreadcrumb(&breadcrumb, nil)
+	}
+
+	if b.Log {
+		b.logRequest(req.Context(), req, url, resp, err, duration)
+		if err == nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			// number of bytes read by the caller is known only when the caller is done with the body
+			onBodyDone = append(onBodyDone, func(read int64, complete bool) {
+				b.logResponseBody(req.Context(), req, url, read, complete)
+			})
+		}
+	}
+
+	if len(onBodyDone) != 0 {
+		wrapResponseBody(resp, responseBody, func(read int64, complete bool) {
+			for _, done := range onBodyDone {
+				done(read, complete)
+			}
+		})
+	}
 
 	return resp, err //nolint:wrapcheck
 }
+
+// spanName returns op and description of the span for the request.
+func (b breadcrumbTransport) spanName(req *http.Request) (string, string) {
+	var op, description string
+	if b.SpanName != nil {
+		op, description = b.SpanName(req)
+	}
+	if op == "" {
+		op = defaultTransportSpanOp
+	}
+	if description == "" {
+		description = req.Method + " " + strippedURL(req.URL)
+	}
+	return op, description
+}
+
+// setRequestSpanData adds data of the request to the span following Sentry conventions for http.client spans.
+// Query and fragment could contain credentials, so they are added only if the scrubber is set.
+func setRequestSpanData(span *sentry.Span, req *http.Request, scrubber *Scrubber) {
+	span.SetData("http.request.method", req.Method)
+	span.SetData("url", strippedURL(req.URL))
+	span.SetData("server.address", req.URL.Hostname())
+	if port, err := strconv.Atoi(req.URL.Port()); err == nil {
+		span.SetData("server.port", port)
+	}
+	if scrubber == nil {
+		return
+	}
+	if query := req.URL.RawQuery; query != "" {
+		span.SetData("http.query", scrubber.ScrubQuery(query))
+	}
+	if fragment := req.URL.Fragment; fragment != "" {
+		span.SetData("http.fragment", scrubber.ScrubQuery(fragment))
+	}
+}
+
+// matchHost reports whether host of the URL matches the glob pattern.
+// Patterns with a port are matched against host with port.
+func matchHost(pattern string, u *url.URL) bool {
+	host := u.Hostname()
+	if strings.Contains(pattern, ":") {
+		host = u.Host
+	}
+	ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(host))
+	return ok
+}
+
+func mustValidHostPattern(pattern string) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic(fmt.Errorf("invalid host pattern %q: %w", pattern, err))
+	}
+}
+
+// strippedURL returns URL without user info, query and fragment.
+func strippedURL(u *url.URL) string {
+	return u.Scheme + "://" + u.Host + u.EscapedPath()
+}
+
+// propagateTo reports whether trace context should be added to the request.
+func (b breadcrumbTransport) propagateTo(req *http.Request) bool {
+	if len(b.PropagationTargets) == 0 {
+		return true
+	}
+	for _, target := range b.PropagationTargets {
+		if target(req) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,8 +2,12 @@ package logger
 
 import (
 	"context"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +15,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
 )
 
 type BreadcrumbTransportSuite struct {
@@ -127,6 +132,486 @@ func (suite *BreadcrumbTransportSuite) TestRoundTripFailure() {
 	suite.hub.Flush(1 * time.Second)
 }
 
+func (suite *BreadcrumbTransportSuite) TestScrubber() {
+	suite.sendEventMock.Do(func(event *sentry.Event) {
+		suite.Require().Len(event.Breadcrumbs, 1, "event should have one breadcrumb")
+		suite.Equal(suite.ts.URL+"?page=1&token=[Filtered]", event.Breadcrumbs[0].Data[BreadcrumbDataURL])
+	}).MinTimes(1)
+
+	client := http.Client{
+		Transport: NewBreadcrumbTransport(sentry.LevelDebug, nil, TransportScrubber(NewScrubber())),
+	}
+
+	ctx := WithHub(context.Background(), suite.hub)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, suite.ts.URL+"?page=1&token=secret", nil)
+	suite.Require().NoError(err)
+
+	resp, err := client.Do(req)
+	suite.Require().NoError(err, "request should be success")
+	defer resp.Body.Close()
+
+	suite.hub.CaptureMessage("test event")
+	suite.hub.Flush(1 * time.Second)
+}
+
+func (suite *BreadcrumbTransportSuite) TestForwardRequestID() {
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	ctx := WithRequestID(WithHub(context.Background(), suite.hub), "request-id")
+
+	suite.Run("default header", func() {
+		client := http.Client{
+			Transport: NewBreadcrumbTransport(sentry.LevelDebug, nil),
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+		suite.Require().NoError(err)
+
+		resp, err := client.Do(req)
+		suite.Require().NoError(err)
+		defer resp.Body.Close()
+
+		suite.Equal("request-id", received.Get("X-Request-Id"))
+	})
+
+	suite.Run("custom header", func() {
+		client := http.Client{
+			Transport: NewBreadcrumbTransport(sentry.LevelDebug, nil, ForwardRequestIDHeader("X-Correlation-Id")),
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+		suite.Require().NoError(err)
+
+		resp, err := client.Do(req)
+		suite.Require().NoError(err)
+		defer resp.Body.Close()
+
+		suite.Equal("request-id", received.Get("X-Correlation-Id"))
+		suite.Empty(received.Get("X-Request-Id"))
+	})
+}
+
+func (suite *BreadcrumbTransportSuite) TestPropagation() {
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	span := sentry.StartSpan(WithHub(context.Background(), suite.hub), "test",
+		sentry.ContinueFromTrace("0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-1"))
+	defer span.Finish()
+
+	suite.Run("default propagators", func() {
+		client := http.Client{
+			Transport: NewBreadcrumbTransport(sentry.LevelDebug, nil),
+		}
+
+		req, err := http.NewRequestWithContext(span.Context(), http.MethodGet, ts.URL, nil)
+		suite.Require().NoError(err)
+		req.Header.Set("baggage", "userId=alice")
+
+		resp, err := client.Do(req)
+		suite.Require().NoError(err)
+		defer resp.Body.Close()
+
+		suite.Regexp(`^0af7651916cd43dd8448eb211c80319c-[[:xdigit:]]{16}-[01]$`, received.Get("sentry-trace"))
+		suite.Regexp(`^00-0af7651916cd43dd8448eb211c80319c-[[:xdigit:]]{16}-0[01]$`, received.Get("traceparent"))
+		suite.Contains(received.Get("baggage"), "userId=alice")
+		suite.Contains(received.Get("baggage"), "sentry-trace_id=0af7651916cd43dd8448eb211c80319c")
+	})
+
+	suite.Run("custom propagators", func() {
+		client := http.Client{
+			Transport: NewBreadcrumbTransport(sentry.LevelDebug, nil, TransportTracePropagators(TraceContextPropagator{})),
+		}
+
+		req, err := http.NewRequestWithContext(span.Context(), http.MethodGet, ts.URL, nil)
+		suite.Require().NoError(err)
+
+		resp, err := client.Do(req)
+		suite.Require().NoError(err)
+		defer resp.Body.Close()
+
+		suite.NotEmpty(received.Get("traceparent"))
+		suite.Empty(received.Get("sentry-trace"))
+		suite.Empty(received.Get("baggage"))
+	})
+}
+
+func (suite *BreadcrumbTransportSuite) TestPropagationTargets() {
+	newRequest := func(url string) *http.Request {
+		req, err := http.NewRequestWithContext(WithHub(context.Background(), suite.hub), http.MethodGet, url, nil)
+		suite.Require().NoError(err)
+		return req
+	}
+
+	targets := func(patterns ...string) []BreadcrumbTransportOption {
+		return []BreadcrumbTransportOption{TransportPropagationTargets(patterns...)}
+	}
+
+	tests := []struct {
+		name    string
+		options []BreadcrumbTransportOption
+		url     string
+		want    bool
+	}{
+		{"no targets", nil, "https://api.example.com/", true},
+		{"host glob", targets("*.example.com"), "https://api.example.com/", true},
+		{"host glob case", targets("*.Example.com"), "https://API.example.com/", true},
+		{"host glob mismatch", targets("*.example.com"), "https://api.stripe.com/", false},
+		{"host with port", targets("localhost:8080"), "http://localhost:8080/", true},
+		{"host with other port", targets("localhost:8080"), "http://localhost:9090/", false},
+		{
+			"regexp",
+			[]BreadcrumbTransportOption{TransportPropagationTargetRegexps(regexp.MustCompile(`^https://example\.com/api/`))},
+			"https://example.com/api/users", true,
+		},
+		{
+			"regexp mismatch",
+			[]BreadcrumbTransportOption{TransportPropagationTargetRegexps(regexp.MustCompile(`^https://example\.com/api/`))},
+			"https://example.com/static/", false,
+		},
+		{
+			"any target",
+			[]BreadcrumbTransportOption{
+				TransportPropagationTargets("internal"),
+				TransportPropagationTargetFunc(func(req *http.Request) bool { return req.URL.Path == "/traced" }),
+			},
+			"https://example.com/traced", true,
+		},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			transport := NewBreadcrumbTransport(sentry.LevelDebug, nil, tt.options...).(*breadcrumbTransport)
+			suite.Equal(tt.want, transport.propagateTo(newRequest(tt.url)))
+		})
+	}
+
+	suite.Panics(func() { TransportPropagationTargets("[") })
+}
+
+func (suite *BreadcrumbTransportSuite) TestPropagationTargetsSkipHeaders() {
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	suite.sendEventMock.Do(func(event *sentry.Event) {
+		suite.Require().Len(event.Breadcrumbs, 1)
+		suite.Equal(ts.URL, event.Breadcrumbs[0].Data[BreadcrumbDataURL])
+	}).Times(1)
+
+	client := http.Client{
+		Transport: NewBreadcrumbTransport(sentry.LevelDebug, nil, TransportPropagationTargets("*.example.com")),
+	}
+
+	ctx := WithRequestID(WithHub(context.Background(), suite.hub), "request-id")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	suite.Require().NoError(err)
+
+	resp, err := client.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+
+	suite.Require().NotNil(received)
+	suite.Empty(received.Get("sentry-trace"))
+	suite.Empty(received.Get("traceparent"))
+	suite.Empty(received.Get("baggage"))
+	suite.Empty(received.Get("X-Request-Id"), "request id should not be forwarded to other hosts")
+
+	suite.hub.CaptureMessage("test event")
+	suite.hub.Flush(1 * time.Second)
+}
+
+func (suite *BreadcrumbTransportSuite) TestTimings() {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	suite.sendEventMock.Do(func(event *sentry.Event) {
+		suite.Require().Len(event.Breadcrumbs, 2)
+
+		data := event.Breadcrumbs[0].Data
+		suite.Contains(data, BreadcrumbDataConnect)
+		suite.Contains(data, BreadcrumbDataTLSHandshake)
+		suite.Contains(data, BreadcrumbDataTTFB)
+		suite.Equal(false, data[BreadcrumbDataConnectionReuse])
+
+		data = event.Breadcrumbs[1].Data
+		suite.NotContains(data, BreadcrumbDataConnect)
+		suite.Contains(data, BreadcrumbDataTTFB)
+		suite.Equal(true, data[BreadcrumbDataConnectionReuse])
+	}).Times(1)
+
+	client := http.Client{
+		Transport: NewBreadcrumbTransport(sentry.LevelDebug, ts.Client().Transport, TransportTimings()),
+	}
+
+	ctx := WithHub(context.Background(), suite.hub)
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+		suite.Require().NoError(err)
+
+		resp, err := client.Do(req)
+		suite.Require().NoError(err)
+		resp.Body.Close()
+	}
+
+	suite.hub.CaptureMessage("test event")
+	suite.hub.Flush(1 * time.Second)
+}
+
+func (suite *BreadcrumbTransportSuite) TestCaptureBodies() {
+	var receivedBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receivedBody = string(body)
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/ok" {
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid card","token":"abc"}`))
+	}))
+	defer ts.Close()
+
+	suite.sendEventMock.Do(func(event *sentry.Event) {
+		suite.Require().Len(event.Breadcrumbs, 5)
+
+		data := event.Breadcrumbs[0].Data
+		suite.Equal(`{"user":"john","password":"[Filtered]"...`, data[BreadcrumbDataRequestBody])
+		suite.NotContains(data, BreadcrumbDataResponseBody)
+
+		data = event.Breadcrumbs[1].Data
+		suite.Equal(ts.URL+"/fail", data[BreadcrumbDataURL])
+		suite.Equal(http.StatusBadRequest, data[BreadcrumbDataStatusCode])
+		suite.JSONEq(`{"error":"invalid card","token":"[Filtered]"}`, data[BreadcrumbDataResponseBody].(string))
+
+		data = event.Breadcrumbs[2].Data
+		suite.Equal("user=john&password=[Filtered]&rem...", data[BreadcrumbDataRequestBody])
+
+		data = event.Breadcrumbs[4].Data
+		suite.NotContains(data, BreadcrumbDataRequestBody, "body should not be captured for successful requests")
+		suite.NotContains(data, BreadcrumbDataResponseBody, "body should not be captured for successful requests")
+	}).Times(1)
+
+	client := http.Client{
+		Transport: NewBreadcrumbTransport(sentry.LevelDebug, nil, CaptureRequestBody(30), CaptureResponseBody(100)),
+	}
+
+	ctx := WithHub(context.Background(), suite.hub)
+	for _, tt := range []struct {
+		path        string
+		contentType string
+		body        string
+		response    string
+	}{
+		{"/fail", "application/json", `{"user":"john","password":"hunter2"}`, `{"error":"invalid card","token":"abc"}`},
+		{"/fail", "application/x-www-form-urlencoded", "user=john&password=hunter2&remember=1", ""},
+		{"/ok", "application/json", `{"user":"john","password":"hunter2"}`, `{"status":"ok"}`},
+	} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.URL+tt.path, strings.NewReader(tt.body))
+		suite.Require().NoError(err)
+		req.Header.Set("Content-Type", tt.contentType)
+
+		resp, err := client.Do(req)
+		suite.Require().NoError(err)
+		body, err := io.ReadAll(resp.Body)
+		suite.Require().NoError(err)
+		resp.Body.Close()
+
+		suite.Equal(tt.body, receivedBody, "server should receive the whole body")
+		if tt.response != "" {
+			suite.Equal(tt.response, string(body), "caller should receive the whole body")
+		}
+	}
+
+	suite.hub.CaptureMessage("test event")
+	suite.hub.Flush(1 * time.Second)
+}
+
+func (suite *BreadcrumbTransportSuite) TestCaptureResponseBodyDoesNotBlock() {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("retry later"))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer ts.Close()
+
+	suite.sendEventMock.Do(func(event *sentry.Event) {
+		suite.Require().Len(event.Breadcrumbs, 2)
+		suite.Equal(http.StatusServiceUnavailable, event.Breadcrumbs[0].Data[BreadcrumbDataStatusCode])
+		suite.Equal("retry later", event.Breadcrumbs[1].Data[BreadcrumbDataResponseBody])
+	}).Times(1)
+
+	client := http.Client{
+		Transport: NewBreadcrumbTransport(sentry.LevelDebug, nil, CaptureResponseBody(100)),
+	}
+	req, err := http.NewRequestWithContext(WithHub(context.Background(), suite.hub), http.MethodGet, ts.URL, nil)
+	suite.Require().NoError(err)
+
+	resp, err := client.Do(req)
+	suite.Require().NoError(err, "response should be returned before the body is complete")
+	close(release)
+	_, err = io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+	resp.Body.Close()
+
+	suite.hub.CaptureMessage("test event")
+	suite.hub.Flush(1 * time.Second)
+}
+
+func (suite *BreadcrumbTransportSuite) TestCaptureResponseBodyBreadcrumbBeforeClose() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("retry later"))
+	}))
+	defer ts.Close()
+
+	suite.sendEventMock.Do(func(event *sentry.Event) {
+		suite.Equal("request failed", event.Message)
+		suite.Require().Len(event.Breadcrumbs, 1, "breadcrumb should be added before the body is closed")
+		suite.Equal(http.StatusServiceUnavailable, event.Breadcrumbs[0].Data[BreadcrumbDataStatusCode])
+	}).Times(1)
+
+	client := http.Client{
+		Transport: NewBreadcrumbTransport(sentry.LevelDebug, nil, CaptureResponseBody(100)),
+	}
+	req, err := http.NewRequestWithContext(WithHub(context.Background(), suite.hub), http.MethodGet, ts.URL, nil)
+	suite.Require().NoError(err)
+
+	resp, err := client.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+
+	zap.New(NewSentryCore(suite.hub)).Error("request failed")
+	suite.hub.Flush(1 * time.Second)
+}
+
+func (suite *BreadcrumbTransportSuite) TestBreadcrumbPolicy() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer ts.Close()
+
+	suite.sendEventMock.Do(func(event *sentry.Event) {
+		suite.Require().Len(event.Breadcrumbs, 2)
+		suite.Equal(sentry.LevelDebug, event.Breadcrumbs[0].Level)
+		suite.Equal(sentry.LevelError, event.Breadcrumbs[1].Level)
+		suite.Equal(http.StatusBadGateway, event.Breadcrumbs[1].Data[BreadcrumbDataStatusCode])
+	}).Times(1)
+
+	client := http.Client{
+		Transport: NewBreadcrumbTransport(sentry.LevelDebug, nil,
+			BreadcrumbStatusLevel(5, sentry.LevelError),
+			BreadcrumbHostSampleRate("localhost", 0),
+		),
+	}
+
+	ctx := WithHub(context.Background(), suite.hub)
+	for _, url := range []string{
+		ts.URL + "/ok",
+		ts.URL + "/fail",
+		strings.Replace(ts.URL, "127.0.0.1", "localhost", 1) + "/fail",
+	} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		suite.Require().NoError(err)
+
+		resp, err := client.Do(req)
+		suite.Require().NoError(err)
+		resp.Body.Close()
+	}
+
+	suite.hub.CaptureMessage("test event")
+	suite.hub.Flush(1 * time.Second)
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func (suite *BreadcrumbTransportSuite) TestSpan() {
+	var span *sentry.Span
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		span = sentry.SpanFromContext(req.Context())
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	do := func(transport http.RoundTripper, url string) {
+		req, err := http.NewRequestWithContext(WithHub(context.Background(), suite.hub), http.MethodGet, url, nil)
+		suite.Require().NoError(err)
+
+		resp, err := (&http.Client{Transport: transport}).Do(req)
+		suite.Require().NoError(err)
+		resp.Body.Close()
+	}
+
+	suite.Run("defaults", func() {
+		do(NewBreadcrumbTransport(sentry.LevelDebug, base, TransportScrubber(NewScrubber())),
+			suite.ts.URL+"/users/1?page=2&token=secret#top")
+
+		suite.Require().NotNil(span)
+		suite.Equal("http.client", span.Op)
+		suite.Equal("GET "+suite.ts.URL+"/users/1", span.Description)
+		suite.Equal("GET", span.Data["http.request.method"])
+		suite.Equal(suite.ts.URL+"/users/1", span.Data["url"])
+		suite.Equal("127.0.0.1", span.Data["server.address"])
+		suite.Equal(suite.ts.Listener.Addr().(*net.TCPAddr).Port, span.Data["server.port"])
+		suite.Equal("page=2&token=[Filtered]", span.Data["http.query"])
+		suite.Equal("top", span.Data["http.fragment"])
+		suite.Equal(http.StatusNoContent, span.Data["http.response.status_code"])
+	})
+
+	suite.Run("without scrubber", func() {
+		do(NewBreadcrumbTransport(sentry.LevelDebug, base), suite.ts.URL+"/users/1?page=2&token=secret#access_token=secret")
+
+		suite.Require().NotNil(span)
+		suite.Equal(suite.ts.URL+"/users/1", span.Data["url"])
+		suite.NotContains(span.Data, "http.query", "query should not be sent unscrubbed")
+		suite.NotContains(span.Data, "http.fragment", "fragment should not be sent unscrubbed")
+	})
+
+	suite.Run("custom name", func() {
+		do(NewBreadcrumbTransport(sentry.LevelDebug, base, TransportSpanName(func(req *http.Request) (string, string) {
+			if strings.HasPrefix(req.URL.Path, "/rpc/") {
+				return "rpc.client", strings.TrimPrefix(req.URL.Path, "/rpc/")
+			}
+			return "", ""
+		})), suite.ts.URL+"/rpc/GetUser")
+
+		suite.Require().NotNil(span)
+		suite.Equal("rpc.client", span.Op)
+		suite.Equal("GetUser", span.Description)
+
+		do(NewBreadcrumbTransport(sentry.LevelDebug, base, TransportSpanName(func(*http.Request) (string, string) {
+			return "", ""
+		})), suite.ts.URL+"/users")
+
+		suite.Equal("http.client", span.Op)
+		suite.Equal("GET "+suite.ts.URL+"/users", span.Description)
+	})
+}
+
 func TestBreadcrumbTransport(t *testing.T) {
 	suite.Run(t, new(BreadcrumbTransportSuite))
 }
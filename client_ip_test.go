@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClientIPResolver(t *testing.T) {
+	_, err := NewClientIPResolver("10.0.0.0/8", "192.168.1.1", "::1", "fd00::/8")
+	require.NoError(t, err)
+
+	_, err = NewClientIPResolver("10.0.0.0/33")
+	require.Error(t, err)
+
+	_, err = NewClientIPResolver("localhost")
+	require.Error(t, err)
+}
+
+func TestClientIP(t *testing.T) {
+	resolver, err := NewClientIPResolver("10.0.0.0/8", "::1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"ipv4 peer", "192.0.2.1:1234", nil, "192.0.2.1"},
+		{"ipv6 peer", "[2001:db8::1]:8080", nil, "2001:db8::1"},
+		{"peer without port", "192.0.2.1", nil, "192.0.2.1"},
+		{"invalid peer", "@", nil, "@"},
+		{
+			"untrusted peer ignores headers", "192.0.2.1:1234",
+			map[string]string{"X-Forwarded-For": "198.51.100.1"}, "192.0.2.1",
+		},
+		{"trusted peer without headers", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{
+			"x-forwarded-for", "10.0.0.1:1234",
+			map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1",
+		},
+		{
+			"x-forwarded-for skips trusted hops", "10.0.0.1:1234",
+			map[string]string{"X-Forwarded-For": "203.0.113.1, 198.51.100.1, 10.0.0.2"}, "198.51.100.1",
+		},
+		{
+			"x-forwarded-for stops on invalid hop", "10.0.0.1:1234",
+			map[string]string{"X-Forwarded-For": "198.51.100.1, unknown, 10.0.0.2"}, "10.0.0.2",
+		},
+		{
+			"x-real-ip", "[::1]:1234",
+			map[string]string{"X-Real-IP": "198.51.100.1"}, "198.51.100.1",
+		},
+		{
+			"forwarded", "10.0.0.1:1234",
+			map[string]string{
+				"Forwarded":       `for="[2001:db8:cafe::17]:4711";proto=http, for=10.0.0.2;by=10.0.0.1`,
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			"2001:db8:cafe::17",
+		},
+	}
+
+	for _, tt := range tests {
+		//nolint:scopelint
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			assert.Equal(t, tt.want, resolver.ClientIP(req))
+		})
+	}
+}
@@ -16,4 +16,16 @@ const (
 	BreadcrumbDataMethod     = "method"
 	BreadcrumbDataStatusCode = "status_code"
 	BreadcrumbDataReason     = "reason"
+
+	BreadcrumbDataRequestBody  = "request_body"
+	BreadcrumbDataResponseBody = "response_body"
+)
+
+// Describes data of HTTP client timings added to an HTTP request breadcrumb by TransportTimings.
+const (
+	BreadcrumbDataDNS             = "dns"
+	BreadcrumbDataConnect         = "connect"
+	BreadcrumbDataTLSHandshake    = "tls_handshake"
+	BreadcrumbDataTTFB            = "ttfb"
+	BreadcrumbDataConnectionReuse = "connection_reused"
 )
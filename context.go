@@ -10,8 +10,15 @@ import (
 type (
 	requestIDCtxKey struct{}
 	zapLoggerCtxKey struct{}
+	spanTraceCtxKey struct{}
 )
 
+// spanTrace keeps the span the in-context logger is bound to and names of trace fields.
+type spanTrace struct {
+	span   *sentry.Span
+	fields TraceFields
+}
+
 func RequestID(ctx context.Context) string {
 	if id, ok := ctx.Value(requestIDCtxKey{}).(string); ok {
 		return id
@@ -49,3 +56,28 @@ func Ctx(ctx context.Context) *zap.Logger {
 func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
 	return context.WithValue(ctx, zapLoggerCtxKey{}, &logger)
 }
+
+// CtxSpan returns the in-context Logger with trace and span ids of the span from the context as fields.
+// Trace fields already bound to the logger are replaced, other fields are kept.
+// Field names are configured by TraceFieldNames option of RequestLogger.
+// If there is no span in the context returns Ctx(ctx).
+func CtxSpan(ctx context.Context) *zap.Logger {
+	span := sentry.SpanFromContext(ctx)
+	if span == nil {
+		return Ctx(ctx)
+	}
+
+	trace, ok := ctx.Value(spanTraceCtxKey{}).(*spanTrace)
+	if !ok {
+		return withTraceFields(Ctx(ctx), defaultTraceFields.fields(span))
+	}
+	if span == trace.span {
+		// in-context logger already has fields of the span
+		return Ctx(ctx)
+	}
+	return withTraceFields(Ctx(ctx), trace.fields.fields(span))
+}
+
+func withSpanTrace(ctx context.Context, trace *spanTrace) context.Context {
+	return context.WithValue(ctx, spanTraceCtxKey{}, trace)
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/getsentry/sentry-go"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 type TestContextHelpersSuite struct {
@@ -46,6 +48,36 @@ func (s *TestContextHelpersSuite) TestLoggerStoring() {
 	s.Equal(logger, Ctx(ctx))
 }
 
+func (s *TestContextHelpersSuite) TestCtxSpan() {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core)
+	ctx := WithLogger(context.Background(), logger)
+
+	s.Equal(logger, CtxSpan(ctx), "logger should not be changed without span")
+
+	span := sentry.StartSpan(ctx, "test")
+	defer span.Finish()
+	CtxSpan(span.Context()).Info("test")
+
+	ctx = WithLogger(span.Context(), CtxSpan(span.Context()).With(zap.String("user", "alice")))
+	childSpan := sentry.StartSpan(ctx, "child")
+	defer childSpan.Finish()
+	CtxSpan(childSpan.Context()).Info("child")
+
+	entries := logs.All()
+	s.Require().Len(entries, 2)
+	s.Equal(map[string]interface{}{
+		"trace_id": span.TraceID.String(),
+		"span_id":  span.SpanID.String(),
+	}, entries[0].ContextMap())
+	s.Len(entries[1].Context, 3, "trace fields should be replaced")
+	s.Equal(map[string]interface{}{
+		"trace_id": span.TraceID.String(),
+		"span_id":  childSpan.SpanID.String(),
+		"user":     "alice",
+	}, entries[1].ContextMap())
+}
+
 func TestContextHelpers(t *testing.T) {
 	suite.Run(t, new(TestContextHelpersSuite))
 }
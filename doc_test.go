@@ -100,10 +100,10 @@ func Example_webServer() {
 		log.Error("let's assume we have an error here")
 	})
 
-	// And use it with our middleware
+	// And use it with our middlewares
 	server := &http.Server{
 		Addr:    ":8080",
-		Handler: RequestLogger(logger)(handler),
+		Handler: RequestLogger(logger)(Recoverer(true)(handler)),
 	}
 
 	_ = server.ListenAndServe()
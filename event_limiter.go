@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	suppressedEventsExtra = "suppressed_events"
+
+	// limiter state is pruned only when there are more fingerprints than this.
+	eventLimiterPruneThreshold = 1024
+)
+
+// EventLimit describes a token bucket: Burst events could be sent at once, then Rate events per second.
+// Zero Burst means no limit.
+type EventLimit struct {
+	Rate  float64
+	Burst int
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) take(limit EventLimit, now time.Time) bool {
+	if limit.Burst == 0 {
+		return true
+	}
+
+	if b.last.IsZero() {
+		b.tokens = float64(limit.Burst)
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * limit.Rate
+		if b.tokens > float64(limit.Burst) {
+			b.tokens = float64(limit.Burst)
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+type eventLimiterEntry struct {
+	bucket     tokenBucket
+	lastSent   time.Time
+	suppressed int
+}
+
+// EventLimiter deduplicates and rate limits events sent by SentryCore.
+// Events are identified by a fingerprint made of message, error type and caller.
+// One limiter could be shared between several cores.
+type EventLimiter struct {
+	mu sync.Mutex
+
+	window           time.Duration
+	fingerprintLimit EventLimit
+	globalLimit      EventLimit
+
+	global  tokenBucket
+	entries map[string]*eventLimiterEntry
+
+	now func() time.Time
+}
+
+// NewEventLimiter creates EventLimiter which will suppress events with the same fingerprint during the window
+// after the event was sent and will apply token buckets per fingerprint and globally.
+// Zero window disables deduplication.
+func NewEventLimiter(window time.Duration, fingerprintLimit, globalLimit EventLimit) *EventLimiter {
+	return &EventLimiter{
+		window:           window,
+		fingerprintLimit: fingerprintLimit,
+		globalLimit:      globalLimit,
+		entries:          make(map[string]*eventLimiterEntry),
+		now:              time.Now,
+	}
+}
+
+// allow reports whether the event with provided fingerprint should be sent and returns the number of events
+// with the same fingerprint suppressed since the last sent one.
+func (l *EventLimiter) allow(fingerprint string) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	entry, ok := l.entries[fingerprint]
+	if !ok {
+		l.prune(now)
+		entry = &eventLimiterEntry{}
+		l.entries[fingerprint] = entry
+	}
+
+	if (l.window > 0 && !entry.lastSent.IsZero() && now.Sub(entry.lastSent) < l.window) ||
+		!entry.bucket.take(l.fingerprintLimit, now) ||
+		!l.global.take(l.globalLimit, now) {
+		entry.suppressed++
+		return false, 0
+	}
+
+	suppressed := entry.suppressed
+	entry.suppressed = 0
+	entry.lastSent = now
+	return true, suppressed
+}
+
+// prune removes entries which could not affect limiting anymore.
+func (l *EventLimiter) prune(now time.Time) {
+	if len(l.entries) < eventLimiterPruneThreshold {
+		return
+	}
+
+	for fingerprint, entry := range l.entries {
+		if entry.suppressed != 0 || now.Sub(entry.lastSent) < l.window {
+			continue
+		}
+		if l.fingerprintLimit.Burst != 0 && entry.bucket.tokens+now.Sub(entry.bucket.last).Seconds()*
+			l.fingerprintLimit.Rate < float64(l.fingerprintLimit.Burst) {
+			continue
+		}
+		delete(l.entries, fingerprint)
+	}
+}
+
+// eventFingerprint returns fingerprint of the event used to deduplicate it.
+func eventFingerprint(ent zapcore.Entry, errField error) string {
+	parts := []string{ent.Message, "", ""}
+	if errField != nil {
+		parts[1] = reflect.TypeOf(errField).String()
+	}
+	if ent.Caller.Defined {
+		parts[2] = ent.Caller.String()
+	}
+	return strings.Join(parts, "\x00")
+}
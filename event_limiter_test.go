@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap/zapcore"
+)
+
+type EventLimiterSuite struct {
+	suite.Suite
+
+	now time.Time
+}
+
+func (s *EventLimiterSuite) SetupTest() {
+	s.now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (s *EventLimiterSuite) newLimiter(window time.Duration, fingerprintLimit, globalLimit EventLimit) *EventLimiter {
+	limiter := NewEventLimiter(window, fingerprintLimit, globalLimit)
+	limiter.now = func() time.Time {
+		return s.now
+	}
+	return limiter
+}
+
+func (s *EventLimiterSuite) assertAllow(limiter *EventLimiter, fingerprint string, allowed bool, suppressed int) {
+	s.T().Helper()
+
+	actualAllowed, actualSuppressed := limiter.allow(fingerprint)
+	s.Equal(allowed, actualAllowed)
+	s.Equal(suppressed, actualSuppressed)
+}
+
+func (s *EventLimiterSuite) TestNoLimits() {
+	limiter := s.newLimiter(0, EventLimit{}, EventLimit{})
+
+	for i := 0; i < 10; i++ {
+		s.assertAllow(limiter, "a", true, 0)
+	}
+}
+
+func (s *EventLimiterSuite) TestDeduplication() {
+	limiter := s.newLimiter(time.Minute, EventLimit{}, EventLimit{})
+
+	s.assertAllow(limiter, "a", true, 0)
+	s.assertAllow(limiter, "a", false, 0)
+	s.assertAllow(limiter, "b", true, 0)
+
+	s.now = s.now.Add(30 * time.Second)
+	s.assertAllow(limiter, "a", false, 0)
+
+	s.now = s.now.Add(30 * time.Second)
+	s.assertAllow(limiter, "a", true, 2)
+	s.assertAllow(limiter, "b", true, 0)
+}
+
+func (s *EventLimiterSuite) TestFingerprintLimit() {
+	limiter := s.newLimiter(0, EventLimit{Rate: 1, Burst: 2}, EventLimit{})
+
+	s.assertAllow(limiter, "a", true, 0)
+	s.assertAllow(limiter, "a", true, 0)
+	s.assertAllow(limiter, "a", false, 0)
+	s.assertAllow(limiter, "b", true, 0)
+
+	s.now = s.now.Add(time.Second)
+	s.assertAllow(limiter, "a", true, 1)
+	s.assertAllow(limiter, "a", false, 0)
+}
+
+func (s *EventLimiterSuite) TestGlobalLimit() {
+	limiter := s.newLimiter(0, EventLimit{}, EventLimit{Rate: 0.5, Burst: 1})
+
+	s.assertAllow(limiter, "a", true, 0)
+	s.assertAllow(limiter, "b", false, 0)
+
+	s.now = s.now.Add(time.Second)
+	s.assertAllow(limiter, "b", false, 0)
+
+	s.now = s.now.Add(time.Second)
+	s.assertAllow(limiter, "b", true, 2)
+}
+
+func (s *EventLimiterSuite) TestPrune() {
+	limiter := s.newLimiter(time.Minute, EventLimit{}, EventLimit{})
+
+	s.assertAllow(limiter, "a", true, 0)
+	s.assertAllow(limiter, "a", false, 0)
+	for i := 1; i < eventLimiterPruneThreshold; i++ {
+		s.assertAllow(limiter, time.Duration(i).String(), true, 0)
+	}
+	s.Equal(eventLimiterPruneThreshold, len(limiter.entries))
+
+	s.now = s.now.Add(time.Minute)
+	s.assertAllow(limiter, "b", true, 0)
+
+	suppressed := make(map[string]int)
+	for fingerprint, entry := range limiter.entries {
+		suppressed[fingerprint] = entry.suppressed
+	}
+	s.Equal(map[string]int{"a": 1, "b": 0}, suppressed, "only entries with suppressed events should be kept")
+}
+
+func (s *EventLimiterSuite) TestEventFingerprint() {
+	ent := zapcore.Entry{Message: "message"}
+	err := errors.New("error") //nolint:goerr113
+
+	s.Equal(eventFingerprint(ent, nil), eventFingerprint(ent, nil))
+	s.NotEqual(eventFingerprint(ent, nil), eventFingerprint(ent, err))
+
+	withCaller := ent
+	withCaller.Caller = zapcore.NewEntryCaller(0, "file.go", 42, true)
+	s.NotEqual(eventFingerprint(ent, err), eventFingerprint(withCaller, err))
+}
+
+func TestEventLimiter(t *testing.T) {
+	suite.Run(t, new(EventLimiterSuite))
+}
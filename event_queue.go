@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// DropPolicy defines what EventQueue does with a new event when it is full.
+type DropPolicy int
+
+const (
+	// DropNewest discards the new event.
+	DropNewest DropPolicy = iota
+	// DropOldest discards the oldest queued event to make room for the new one.
+	DropOldest
+	// BlockWhenFull blocks the logging goroutine until there is room in the queue.
+	BlockWhenFull
+)
+
+// EventQueueStats contains counters of events passed through EventQueue.
+type EventQueueStats struct {
+	Enqueued uint64
+	Dropped  uint64
+	Sent     uint64
+}
+
+type queuedEvent struct {
+	client *sentry.Client
+	scope  sentry.EventModifier
+	event  *sentry.Event
+}
+
+// EventQueue delivers events to Sentry in a background goroutine, so logging is never blocked by the transport.
+// One queue could be shared between several cores.
+type EventQueue struct {
+	events chan queuedEvent
+	policy DropPolicy
+	start  sync.Once
+
+	// closeMu guards events channel from being closed while enqueue sends to it
+	closeMu sync.RWMutex
+	closed  bool
+
+	mu      sync.Mutex
+	pending int
+	drained chan struct{}
+
+	enqueued atomic.Uint64
+	dropped  atomic.Uint64
+	sent     atomic.Uint64
+}
+
+// NewEventQueue creates EventQueue which can hold up to size events waiting for delivery.
+// Size less than 1 is replaced with 1.
+func NewEventQueue(size int, policy DropPolicy) *EventQueue {
+	if size < 1 {
+		size = 1
+	}
+	return &EventQueue{
+		events: make(chan queuedEvent, size),
+		policy: policy,
+	}
+}
+
+// Stats returns current values of queue counters.
+func (q *EventQueue) Stats() EventQueueStats {
+	return EventQueueStats{
+		Enqueued: q.enqueued.Load(),
+		Dropped:  q.dropped.Load(),
+		Sent:     q.sent.Load(),
+	}
+}
+
+// Close stops accepting new events and stops the background goroutine once already queued events are delivered.
+// Events enqueued after Close are dropped. Use flush (e.g. with SentryCore.Sync) to wait for queued events.
+func (q *EventQueue) Close() {
+	q.closeMu.Lock()
+	defer q.closeMu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.events)
+}
+
+// enqueue adds the event to the queue according to the drop policy and reports whether it was added.
+func (q *EventQueue) enqueue(item queuedEvent) bool {
+	q.closeMu.RLock()
+	defer q.closeMu.RUnlock()
+
+	if q.closed {
+		q.dropped.Add(1)
+		return false
+	}
+
+	q.start.Do(func() {
+		go q.run()
+	})
+
+	q.addPending(1)
+	switch q.policy {
+	case BlockWhenFull:
+		q.events <- item
+	case DropOldest:
+		for !q.trySend(item) {
+			select {
+			case <-q.events:
+				q.dropped.Add(1)
+				q.addPending(-1)
+			default:
+			}
+		}
+	case DropNewest:
+		fallthrough
+	default:
+		if !q.trySend(item) {
+			q.dropped.Add(1)
+			q.addPending(-1)
+			return false
+		}
+	}
+	q.enqueued.Add(1)
+	return true
+}
+
+func (q *EventQueue) trySend(item queuedEvent) bool {
+	select {
+	case q.events <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *EventQueue) run() {
+	for item := range q.events {
+		item.client.CaptureEvent(item.event, nil, item.scope)
+		q.sent.Add(1)
+		q.addPending(-1)
+	}
+}
+
+func (q *EventQueue) addPending(delta int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending += delta
+	if q.pending == 0 && q.drained != nil {
+		close(q.drained)
+		q.drained = nil
+	}
+}
+
+// flush waits until all queued events are passed to the client and reports whether it happened before the timeout.
+func (q *EventQueue) flush(timeout time.Duration) bool {
+	q.mu.Lock()
+	if q.pending == 0 {
+		q.mu.Unlock()
+		return true
+	}
+	if q.drained == nil {
+		q.drained = make(chan struct{})
+	}
+	drained := q.drained
+	q.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
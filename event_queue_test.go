@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+)
+
+type EventQueueSuite struct {
+	suite.Suite
+
+	ctrl   *gomock.Controller
+	client *sentry.Client
+
+	received chan string
+	release  chan struct{}
+}
+
+func (s *EventQueueSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.received = make(chan string, 10)
+	s.release = make(chan struct{})
+
+	transportMock := NewMockTransport(s.ctrl)
+	transportMock.EXPECT().
+		Configure(gomock.AssignableToTypeOf(sentry.ClientOptions{})).
+		Return()
+	transportMock.EXPECT().
+		SendEvent(gomock.AssignableToTypeOf(&sentry.Event{})).
+		Do(func(event *sentry.Event) {
+			s.received <- event.Message
+			// emulating slow synchronous transport
+			<-s.release
+		}).
+		Return().
+		MinTimes(0)
+
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transportMock})
+	s.Require().NoError(err)
+	s.client = client
+}
+
+func (s *EventQueueSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *EventQueueSuite) enqueue(queue *EventQueue, message string) bool {
+	event := sentry.NewEvent()
+	event.Message = message
+	return queue.enqueue(queuedEvent{client: s.client, scope: sentry.NewScope(), event: event})
+}
+
+// fillQueue enqueues the first event and waits until it is picked by the worker.
+func (s *EventQueueSuite) fillQueue(queue *EventQueue) {
+	s.enqueue(queue, "first")
+	s.Equal("first", <-s.received)
+	s.enqueue(queue, "second")
+}
+
+func (s *EventQueueSuite) drain(queue *EventQueue) []string {
+	close(s.release)
+	s.Require().True(queue.flush(time.Second))
+	close(s.received)
+
+	var messages []string
+	for message := range s.received {
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func (s *EventQueueSuite) TestDropNewest() {
+	queue := NewEventQueue(1, DropNewest)
+	s.fillQueue(queue)
+	s.enqueue(queue, "third")
+
+	s.Equal([]string{"second"}, s.drain(queue))
+	s.Equal(EventQueueStats{Enqueued: 2, Dropped: 1, Sent: 2}, queue.Stats())
+}
+
+func (s *EventQueueSuite) TestDropOldest() {
+	queue := NewEventQueue(1, DropOldest)
+	s.fillQueue(queue)
+	s.enqueue(queue, "third")
+
+	s.Equal([]string{"third"}, s.drain(queue))
+	s.Equal(EventQueueStats{Enqueued: 3, Dropped: 1, Sent: 2}, queue.Stats())
+}
+
+func (s *EventQueueSuite) TestBlockWhenFull() {
+	queue := NewEventQueue(1, BlockWhenFull)
+	s.fillQueue(queue)
+
+	enqueued := make(chan struct{})
+	go func() {
+		s.enqueue(queue, "third")
+		close(enqueued)
+	}()
+
+	select {
+	case <-enqueued:
+		s.Fail("enqueue should block while the queue is full")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	s.Equal([]string{"second", "third"}, s.drain(queue))
+	<-enqueued
+	s.Equal(EventQueueStats{Enqueued: 3, Dropped: 0, Sent: 3}, queue.Stats())
+}
+
+func (s *EventQueueSuite) TestFlushTimeout() {
+	queue := NewEventQueue(1, DropNewest)
+	s.True(queue.flush(time.Millisecond), "empty queue should be flushed immediately")
+
+	s.fillQueue(queue)
+	s.False(queue.flush(10 * time.Millisecond))
+
+	s.Equal([]string{"second"}, s.drain(queue))
+}
+
+func (s *EventQueueSuite) TestZeroSize() {
+	queue := NewEventQueue(0, DropOldest)
+	s.fillQueue(queue)
+	s.True(s.enqueue(queue, "third"), "enqueue should not spin on zero capacity queue")
+
+	s.Equal([]string{"third"}, s.drain(queue))
+	s.Equal(EventQueueStats{Enqueued: 3, Dropped: 1, Sent: 2}, queue.Stats())
+}
+
+func (s *EventQueueSuite) TestClose() {
+	queue := NewEventQueue(1, DropNewest)
+	s.fillQueue(queue)
+
+	queue.Close()
+	queue.Close()
+	s.False(s.enqueue(queue, "third"))
+
+	s.Equal([]string{"second"}, s.drain(queue), "queued events should be delivered after Close")
+	s.Equal(EventQueueStats{Enqueued: 2, Dropped: 1, Sent: 2}, queue.Stats())
+}
+
+func TestEventQueue(t *testing.T) {
+	suite.Run(t, new(EventQueueSuite))
+}
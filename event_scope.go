@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+)
+
+//nolint:gochecknoglobals
+var (
+	// pendingEvents keeps data of events captured by SentryCore with Hub.CaptureEvent by event ID.
+	// Entries are removed as soon as the capture returns, so nothing is kept for events dropped by the client.
+	pendingEvents sync.Map
+	// registerPendingEventsProcessor adds the global processor applying data of pending events.
+	registerPendingEventsProcessor sync.Once
+)
+
+// eventScope is a sentry.EventModifier applying the hub scope and data specific to the event created by SentryCore.
+type eventScope struct {
+	scope    *sentry.Scope
+	user     sentry.User
+	scrubber *Scrubber
+}
+
+func (e *eventScope) ApplyToEvent(event *sentry.Event, hint *sentry.EventHint, client *sentry.Client) *sentry.Event {
+	event = e.scope.ApplyToEvent(event, hint, client)
+	if event == nil {
+		return nil
+	}
+	return e.applyEventData(event, hint)
+}
+
+// applyEventData merges logged user and scrubs the event, scope data should be already applied to the event.
+func (e *eventScope) applyEventData(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+	// scope user is applied to the event only if event user is empty, so logged user is merged afterwards
+	mergeUser(&event.User, e.user)
+
+	if e.scrubber != nil {
+		// scrubber is applied after scope data (e.g. request) is added to the event
+		event = e.scrubber.ProcessEvent(event, hint)
+	}
+	return event
+}
+
+// processPendingEvent is a global event processor applying data of events captured with captureWithHub.
+func processPendingEvent(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+	if data, ok := pendingEvents.Load(event.EventID); ok {
+		return data.(*eventScope).applyEventData(event, hint) //nolint:forcetypeassert
+	}
+	return event
+}
+
+// captureWithHub sends the event with Hub.CaptureEvent, so Hub.LastEventID is updated, and applies event data
+// after the hub scope with the global event processor.
+func captureWithHub(hub *sentry.Hub, event *sentry.Event, data *eventScope) *sentry.EventID {
+	event.EventID = newEventID()
+	pendingEvents.Store(event.EventID, data)
+	defer pendingEvents.Delete(event.EventID)
+
+	return hub.CaptureEvent(event)
+}
+
+// mergeUser overrides user fields which are not empty in the logged user.
+func mergeUser(user *sentry.User, logged sentry.User) {
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&user.ID, logged.ID},
+		{&user.IPAddress, logged.IPAddress},
+		{&user.Name, logged.Name},
+		{&user.Username, logged.Username},
+		{&user.Email, logged.Email},
+		{&user.Segment, logged.Segment},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+
+	if len(logged.Data) != 0 {
+		// scope user data is shared between events
+		data := make(map[string]string, len(user.Data)+len(logged.Data))
+		for key, val := range user.Data {
+			data[key] = val
+		}
+		for key, val := range logged.Data {
+			data[key] = val
+		}
+		user.Data = data
+	}
+}
+
+// newEventID returns random event ID in the same format as generated by sentry-go.
+func newEventID() sentry.EventID {
+	return sentry.EventID(generateRequestID())
+}
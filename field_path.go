@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+const fieldPathSeparator = "."
+
+// popPath removes value from the fields map by dotted path, e.g. "user.id" for zap.Object("user", ...).
+// Keys containing dots are also supported. Nested maps are copied before modification because they are
+// shared with breadcrumb data, empty maps left after removal are removed as well.
+func popPath(fieldMap map[string]interface{}, fieldPath string) (interface{}, bool) {
+	if fieldPath == "" {
+		return nil, false
+	}
+
+	if val, ok := fieldMap[fieldPath]; ok {
+		delete(fieldMap, fieldPath)
+		return val, true
+	}
+
+	for i := strings.Index(fieldPath, fieldPathSeparator); i != -1; {
+		key, rest := fieldPath[:i], fieldPath[i+1:]
+		if nested, ok := fieldMap[key].(map[string]interface{}); ok {
+			nested = mergeFields(nested, nil)
+			if val, found := popPath(nested, rest); found {
+				if len(nested) == 0 {
+					delete(fieldMap, key)
+				} else {
+					fieldMap[key] = nested
+				}
+				return val, true
+			}
+		}
+
+		next := strings.Index(rest, fieldPathSeparator)
+		if next == -1 {
+			break
+		}
+		i += next + 1
+	}
+	return nil, false
+}
+
+// isFieldPattern reports whether the field path is a glob pattern.
+func isFieldPattern(fieldPath string) bool {
+	return strings.ContainsAny(fieldPath, `*?[\`)
+}
+
+// matchPaths returns sorted dotted paths of leaf fields matching the pattern.
+// Wildcards don't match dots, so "http.*" matches "http.method" but not "http.request.method".
+func matchPaths(fieldMap map[string]interface{}, pattern string) []string {
+	pattern = strings.ReplaceAll(pattern, fieldPathSeparator, "/")
+
+	var matched []string
+	walkFields(fieldMap, "", func(fieldPath string) {
+		if ok, _ := path.Match(pattern, strings.ReplaceAll(fieldPath, fieldPathSeparator, "/")); ok {
+			matched = append(matched, fieldPath)
+		}
+	})
+	sort.Strings(matched)
+	return matched
+}
+
+func walkFields(fieldMap map[string]interface{}, prefix string, fn func(fieldPath string)) {
+	for key, value := range fieldMap {
+		if prefix != "" {
+			key = prefix + fieldPathSeparator + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			walkFields(nested, key, fn)
+		} else {
+			fn(key)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPopPath(t *testing.T) {
+	user := map[string]interface{}{"id": "42", "org": map[string]interface{}{"id": "7"}}
+	fields := map[string]interface{}{
+		"user":        user,
+		"dotted.key":  "value",
+		"dotted":      map[string]interface{}{"other": true},
+		"single":      map[string]interface{}{"only": 1},
+		"not_a_group": "string",
+	}
+
+	val, ok := popPath(fields, "user.org.id")
+	assert.True(t, ok)
+	assert.Equal(t, "7", val)
+	assert.Equal(t, map[string]interface{}{"id": "42"}, fields["user"], "empty groups should be removed")
+	assert.Contains(t, user, "org", "nested maps should not be modified in place")
+
+	val, ok = popPath(fields, "dotted.key")
+	assert.True(t, ok)
+	assert.Equal(t, "value", val)
+
+	val, ok = popPath(fields, "single.only")
+	assert.True(t, ok)
+	assert.Equal(t, 1, val)
+	assert.NotContains(t, fields, "single")
+
+	for _, missing := range []string{"", "user.name", "not_a_group.key", "dotted.key"} {
+		_, ok = popPath(fields, missing)
+		assert.False(t, ok, missing)
+	}
+}
+
+func TestMatchPaths(t *testing.T) {
+	fields := map[string]interface{}{
+		"http": map[string]interface{}{
+			"method":  "GET",
+			"status":  200,
+			"request": map[string]interface{}{"method": "GET"},
+		},
+		"http_version": "1.1",
+	}
+
+	assert.Equal(t, []string{"http.method", "http.status"}, matchPaths(fields, "http.*"))
+	assert.Equal(t, []string{"http.method"}, matchPaths(fields, "http.*method"), "wildcards should not match dots")
+	assert.Equal(t, []string{"http.request.method"}, matchPaths(fields, "*.*.method"))
+	assert.Equal(t, []string{"http_version"}, matchPaths(fields, "http_*"))
+	assert.Empty(t, matchPaths(fields, "user.*"))
+
+	assert.True(t, isFieldPattern("http.*"))
+	assert.False(t, isFieldPattern("http.method"))
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// FingerprintDefault could be used in fingerprint to extend default Sentry grouping instead of replacing it.
+const FingerprintDefault = "{{ default }}"
+
+// FingerprintFunc returns fingerprint of the event created from the entry, fields and error.
+// Empty fingerprint means default Sentry grouping.
+type FingerprintFunc func(ent zapcore.Entry, data map[string]interface{}, err error) []string
+
+// FingerprintByMessage groups events by message only.
+func FingerprintByMessage(ent zapcore.Entry, _ map[string]interface{}, _ error) []string {
+	return []string{ent.Message}
+}
+
+// FingerprintByMessageAndRootError groups events by message and type of the innermost wrapped error.
+func FingerprintByMessageAndRootError(ent zapcore.Entry, _ map[string]interface{}, err error) []string {
+	if err == nil {
+		return []string{ent.Message}
+	}
+	return []string{ent.Message, reflect.TypeOf(rootError(err)).String()}
+}
+
+// FingerprintByCaller groups events by message and the place they were logged from.
+func FingerprintByCaller(ent zapcore.Entry, _ map[string]interface{}, _ error) []string {
+	if !ent.Caller.Defined {
+		return []string{ent.Message}
+	}
+	return []string{ent.Message, ent.Caller.TrimmedPath()}
+}
+
+func rootError(err error) error {
+	for i := 0; i < 10; i++ {
+		var next error
+		switch wrapped := err.(type) { //nolint:errorlint
+		case interface{ Unwrap() error }:
+			next = wrapped.Unwrap()
+		case interface{ Cause() error }:
+			next = wrapped.Cause()
+		}
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+	return err
+}
+
+// fingerprintFromField converts value of the fingerprint field to the list of strings.
+func fingerprintFromField(value interface{}) []string {
+	switch typed := value.(type) {
+	case nil:
+		return nil
+	case string:
+		if typed == "" {
+			return nil
+		}
+		return []string{typed}
+	case []string:
+		return typed
+	case []interface{}:
+		fingerprint := make([]string, len(typed))
+		for i, part := range typed {
+			fingerprint[i] = fmt.Sprint(part)
+		}
+		return fingerprint
+	default:
+		return []string{fmt.Sprint(typed)}
+	}
+}
@@ -0,0 +1,34 @@
+package logger
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestFingerprintStrategies(t *testing.T) {
+	ent := zapcore.Entry{
+		Message: "request failed",
+		Caller:  zapcore.NewEntryCaller(0, "/src/go.pr0ger.dev/logger/handler.go", 42, true),
+	}
+	err := fmt.Errorf("wrapped: %w", errors.Wrap(stderrors.New("timeout"), "with stack"))
+
+	assert.Equal(t, []string{"request failed"}, FingerprintByMessage(ent, nil, err))
+	assert.Equal(t, []string{"request failed", "*errors.errorString"}, FingerprintByMessageAndRootError(ent, nil, err))
+	assert.Equal(t, []string{"request failed"}, FingerprintByMessageAndRootError(ent, nil, nil))
+	assert.Equal(t, []string{"request failed", "logger/handler.go:42"}, FingerprintByCaller(ent, nil, err))
+	assert.Equal(t, []string{"request failed"}, FingerprintByCaller(zapcore.Entry{Message: "request failed"}, nil, err))
+}
+
+func TestFingerprintFromField(t *testing.T) {
+	assert.Nil(t, fingerprintFromField(nil))
+	assert.Nil(t, fingerprintFromField(""))
+	assert.Equal(t, []string{"a"}, fingerprintFromField("a"))
+	assert.Equal(t, []string{"a", "b"}, fingerprintFromField([]string{"a", "b"}))
+	assert.Equal(t, []string{"a", "1"}, fingerprintFromField([]interface{}{"a", int64(1)}))
+	assert.Equal(t, []string{"42"}, fingerprintFromField(42))
+}
@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// httpTimings collects timings of an outgoing request with httptrace.
+// Callbacks could be called concurrently by dialers, so all fields are guarded by mu.
+type httpTimings struct {
+	mu sync.Mutex
+
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	wroteRequest, firstByte   time.Time
+
+	gotConn bool
+	reused  bool
+}
+
+func (t *httpTimings) clientTrace() *httptrace.ClientTrace {
+	// set stores the time if it was not set yet, so only the first attempt of parallel dials is recorded
+	set := func(field *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if field.IsZero() {
+			*field = time.Now()
+		}
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart:     func(httptrace.DNSStartInfo) { set(&t.dnsStart) },
+		DNSDone:      func(httptrace.DNSDoneInfo) { set(&t.dnsDone) },
+		ConnectStart: func(_, _ string) { set(&t.connectStart) },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				set(&t.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() { set(&t.tlsStart) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				set(&t.tlsDone)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.gotConn = true
+			t.reused = info.Reused
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { set(&t.wroteRequest) },
+		GotFirstResponseByte: func() { set(&t.firstByte) },
+	}
+}
+
+// record adds collected timings to the breadcrumb data and creates child spans of the request span for them.
+func (t *httpTimings) record(span *sentry.Span, data map[string]interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, phase := range []struct {
+		key, op    string
+		start, end time.Time
+	}{
+		{BreadcrumbDataDNS, "http.client.dns", t.dnsStart, t.dnsDone},
+		{BreadcrumbDataConnect, "http.client.connect", t.connectStart, t.connectDone},
+		{BreadcrumbDataTLSHandshake, "http.client.tls", t.tlsStart, t.tlsDone},
+		{"", "http.client.server", t.wroteRequest, t.firstByte},
+	} {
+		if phase.start.IsZero() || phase.end.IsZero() {
+			continue
+		}
+		if phase.key != "" {
+			data[phase.key] = phase.end.Sub(phase.start).String()
+		}
+
+		child := sentry.StartSpan(span.Context(), phase.op)
+		child.StartTime = phase.start
+		child.EndTime = phase.end
+		child.Finish()
+	}
+
+	if !t.firstByte.IsZero() {
+		data[BreadcrumbDataTTFB] = t.firstByte.Sub(span.StartTime).String()
+	}
+	if t.gotConn {
+		data[BreadcrumbDataConnectionReuse] = t.reused
+		span.SetData("http.connection_reused", t.reused)
+	}
+}
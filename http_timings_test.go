@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPTimings(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var transaction *sentry.Event
+	transportMock := NewMockTransport(ctrl)
+	transportMock.EXPECT().Configure(gomock.Any()).Return()
+	transportMock.EXPECT().
+		SendEvent(gomock.AssignableToTypeOf(&sentry.Event{})).
+		Do(func(event *sentry.Event) { transaction = event }).
+		Return().
+		Times(1)
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Transport:        transportMock,
+		EnableTracing:    true,
+		TracesSampleRate: 1,
+	})
+	require.NoError(t, err)
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	span := sentry.StartSpan(WithHub(context.Background(), hub), "http.client")
+
+	timings := &httpTimings{}
+	trace := timings.clientTrace()
+	trace.DNSStart(httptrace.DNSStartInfo{Host: "example.com"})
+	trace.DNSDone(httptrace.DNSDoneInfo{})
+	trace.ConnectStart("tcp", "127.0.0.1:443")
+	trace.ConnectStart("tcp", "[::1]:443")
+	trace.ConnectDone("tcp", "[::1]:443", context.Canceled)
+	trace.ConnectDone("tcp", "127.0.0.1:443", nil)
+	trace.TLSHandshakeStart()
+	trace.TLSHandshakeDone(tls.ConnectionState{}, nil)
+	trace.GotConn(httptrace.GotConnInfo{Reused: false})
+	trace.WroteRequest(httptrace.WroteRequestInfo{})
+	time.Sleep(time.Millisecond)
+	trace.GotFirstResponseByte()
+
+	data := make(map[string]interface{})
+	timings.record(span, data)
+	span.Finish()
+
+	for _, key := range []string{
+		BreadcrumbDataDNS, BreadcrumbDataConnect, BreadcrumbDataTLSHandshake, BreadcrumbDataTTFB,
+	} {
+		assert.IsType(t, "", data[key], key)
+	}
+	assert.Equal(t, false, data[BreadcrumbDataConnectionReuse])
+
+	require.NotNil(t, transaction)
+	assert.Equal(t, false, transaction.Extra["http.connection_reused"])
+
+	var ops []string
+	for _, child := range transaction.Spans {
+		assert.Equal(t, span.SpanID, child.ParentSpanID)
+		assert.False(t, child.EndTime.Before(child.StartTime))
+		ops = append(ops, child.Op)
+	}
+	assert.ElementsMatch(t, []string{"http.client.dns", "http.client.connect", "http.client.tls", "http.client.server"}, ops)
+}
+
+func TestHTTPTimingsReusedConnection(t *testing.T) {
+	t.Parallel()
+
+	span := sentry.StartSpan(context.Background(), "http.client")
+	defer span.Finish()
+
+	timings := &httpTimings{}
+	trace := timings.clientTrace()
+	trace.GotConn(httptrace.GotConnInfo{Reused: true})
+	trace.WroteRequest(httptrace.WroteRequestInfo{})
+	trace.GotFirstResponseByte()
+
+	data := make(map[string]interface{})
+	timings.record(span, data)
+
+	assert.Equal(t, true, data[BreadcrumbDataConnectionReuse])
+	assert.Contains(t, data, BreadcrumbDataTTFB)
+	assert.NotContains(t, data, BreadcrumbDataDNS)
+	assert.NotContains(t, data, BreadcrumbDataConnect)
+	assert.NotContains(t, data, BreadcrumbDataTLSHandshake)
+}
@@ -24,6 +24,24 @@ func SentryLevel(level zapcore.Level) sentry.Level {
 	}
 }
 
+// sentryLogLevel returns Sentry Logs level and OpenTelemetry severity number.
+func sentryLogLevel(level zapcore.Level) (string, int) {
+	switch level {
+	case zapcore.DebugLevel:
+		return "debug", 5 //nolint:gomnd
+	case zapcore.InfoLevel:
+		return "info", 9 //nolint:gomnd
+	case zapcore.WarnLevel:
+		return "warn", 13 //nolint:gomnd
+	case zapcore.ErrorLevel, zapcore.DPanicLevel:
+		return "error", 17 //nolint:gomnd
+	case zapcore.PanicLevel, zapcore.FatalLevel, zapcore.InvalidLevel:
+		return "fatal", 21 //nolint:gomnd
+	default:
+		return "trace", 1
+	}
+}
+
 //nolint:cyclop
 func SpanStatus(httpCode int) sentry.SpanStatus {
 	if http.StatusOK <= httpCode && httpCode < 299 {
@@ -34,6 +34,32 @@ func TestSentryLevel(t *testing.T) {
 	}
 }
 
+func TestSentryLogLevel(t *testing.T) {
+	tests := []struct {
+		arg      zapcore.Level
+		want     string
+		severity int
+	}{
+		{zapcore.DebugLevel, "debug", 5},
+		{zapcore.InfoLevel, "info", 9},
+		{zapcore.WarnLevel, "warn", 13},
+		{zapcore.ErrorLevel, "error", 17},
+		{zapcore.DPanicLevel, "error", 17},
+		{zapcore.PanicLevel, "fatal", 21},
+		{zapcore.FatalLevel, "fatal", 21},
+		{zapcore.Level(math.MinInt8), "trace", 1},
+	}
+
+	for _, tt := range tests {
+		//nolint:scopelint
+		t.Run(tt.arg.String(), func(t *testing.T) {
+			level, severity := sentryLogLevel(tt.arg)
+			assert.Equal(t, tt.want, level)
+			assert.Equal(t, tt.severity, severity)
+		})
+	}
+}
+
 func TestSpanStatus(t *testing.T) {
 	tests := []struct {
 		arg  int
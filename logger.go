@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"strings"
+	"reflect"
 	"time"
 
 	"github.com/getsentry/sentry-go"
@@ -41,75 +41,108 @@ func NewCore(debug bool) zapcore.Core {
 // If provided logger has sentryCoreWrapper as core injected logger will have core with same local core and
 // sentry core based on an empty Hub for each request so breadcrumbs list will be empty each time.
 // In other case logger.Core() will be used as a local core and sentry core will be created if sentry is initialized.
-func RequestLogger(logger *zap.Logger) func(next http.Handler) http.Handler {
+// Request id is taken from the request header or generated, and is available with RequestID, as a logger field
+// and as a Sentry tag. Access log, request id and Sentry event id headers can be configured with RequestLoggerOption.
+func RequestLogger(logger *zap.Logger, options ...RequestLoggerOption) func(next http.Handler) http.Handler {
+	config := newRequestLoggerConfig(options)
+
 	localCore := logger.Core()
 	client := sentry.CurrentHub().Client()
-	var options []SentryCoreOption
+	var coreOptions []SentryCoreOption
+	var scrubber *Scrubber
 	if wrappedCore, ok := localCore.(sentryCoreWrapper); ok {
 		localCore = wrappedCore.LocalCore()
 		sentryCore := wrappedCore.SentryCore()
 		client = sentryCore.hub.Client()
-		options = prepareOptions(sentryCore)
+		coreOptions = prepareOptions(sentryCore)
+		scrubber = sentryCore.Scrubber
 	}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
+			skip := config.skip != nil && config.skip(r)
 
 			ww := NewWrapResponseWriter(w, r.ProtoMajor)
+			clientIP := config.clientIP.ClientIP(r)
+
+			requestID := config.requestID(r)
+			ctx = WithRequestID(ctx, requestID)
+			if config.requestIDHeader != "" {
+				ww.Header().Set(config.requestIDHeader, requestID)
+			}
 
 			var span *sentry.Span
-			var loggerOptions []zap.Option
-			core := localCore
+			var sentryCore *SentryCore
+			// request id is passed to Sentry as a tag, so only local core needs it as a field
+			requestCore := localCore.With([]zapcore.Field{zap.String(RequestIDKey, requestID)})
 			if client != nil {
-				hub := sentry.NewHub(client, sentry.NewScope())
+				hub := newScrubbedHub(client, scrubber)
 				hub.Scope().SetRequest(r)
 				hub.Scope().SetUser(
 					sentry.User{
-						IPAddress: strings.Split(r.RemoteAddr, ":")[0],
+						IPAddress: clientIP,
 					},
 				)
+				hub.Scope().SetTag(RequestIDKey, requestID)
 
 				ctx = WithHub(ctx, hub)
 
-				span = sentry.StartSpan(ctx, "http.handler",
-					sentry.WithTransactionName(fmt.Sprintf("%s %s", r.Method, r.URL.Path)),
-					sentry.ContinueFromRequest(r),
-				)
-				ctx = span.Context() //nolint:contextcheck
+				if !skip {
+					span = sentry.StartSpan(ctx, "http.handler",
+						sentry.WithTransactionName(fmt.Sprintf("%s %s", r.Method, r.URL.Path)),
+						sentry.WithTransactionSource(sentry.SourceURL),
+						continueFromHeaders(config.propagators, r.Header),
+					)
+					ctx = span.Context() //nolint:contextcheck
+				}
 
-				core = NewSentryCoreWrapper(localCore, hub, options...)
+				sentryCore = NewSentryCore(hub, coreOptions...).(*SentryCore) //nolint: forcetypeassert
 
-				loggerOptions = append(loggerOptions, zap.Hooks(func(entry zapcore.Entry) error {
-					//nolint: forcetypeassert
-					if entry.Level >= core.(sentryCoreWrapper).SentryCore().EventLevel && hub.LastEventID() != "" {
-						ww.Header().Add(sentryEventIDHeader, string(hub.LastEventID()))
+				if config.eventIDHeader != "" {
+					// the header is set by the core instead of a logger hook, so the core stays sentryCoreWrapper
+					sentryCore.onEvent = func(eventID sentry.EventID) {
+						ww.Header().Add(config.eventIDHeader, string(eventID))
 					}
-					return nil
-				}))
+				}
 			}
 
-			requestLogger := zap.New(core, loggerOptions...)
-			ctx = WithLogger(ctx, requestLogger)
+			if fields := config.traceFields.fields(span); len(fields) != 0 {
+				// trace context is passed to Sentry by the hub, so only local core needs it as fields
+				requestCore = newTraceCore(requestCore, fields)
+			}
+			if sentryCore != nil {
+				requestCore = sentryCoreWrapper{requestCore, sentryCore}
+			}
+			ctx = WithLogger(ctx, zap.New(requestCore))
+			ctx = withSpanTrace(ctx, &spanTrace{span: span, fields: config.traceFields})
+
+			// http.ServeMux stores matched pattern in the request it was called with
+			req := r.WithContext(ctx)
 
 			t1 := time.Now()
 			defer func() {
 				if span != nil {
-					span.Status = SpanStatus(ww.Status())
+					// status could be already set by Recoverer
+					if span.Status == sentry.SpanStatusUndefined {
+						span.Status = SpanStatus(ww.Status())
+					}
+					if name := config.routeTransactionName(req); name != "" {
+						span.Name = name
+						span.Source = sentry.SourceRoute
+					}
 					span.Finish()
 				}
+				if skip {
+					return
+				}
 				// fetching logger from context because it can be changed by WithExtraFields middleware
-				Ctx(ctx).Debug("-",
-					zap.Duration("duration", time.Since(t1)),
-					zap.Int("status", ww.Status()),
-					zap.Int("size", ww.BytesWritten()),
-					zap.String("method", r.Method),
-					zap.String("url", r.URL.String()),
-					zap.String("ip", r.RemoteAddr),
-				)
+				if ce := Ctx(ctx).Check(config.accessLogLevel(ww.Status()), config.accessLogMessage); ce != nil {
+					ce.Write(config.accessLogEntryFields(r, ww, time.Since(t1), clientIP)...)
+				}
 			}()
 
-			next.ServeHTTP(ww, r.WithContext(ctx))
+			next.ServeHTTP(ww, req)
 		})
 	}
 }
@@ -142,12 +175,22 @@ func ForkedLogger(logger *zap.Logger) *zap.Logger {
 	sentryCore := wrappedCore.SentryCore()
 	options := prepareOptions(sentryCore)
 
-	hub := sentry.NewHub(sentryCore.hub.Client(), sentry.NewScope())
+	hub := newScrubbedHub(sentryCore.hub.Client(), sentryCore.Scrubber)
 	core := NewSentryCoreWrapper(localCore, hub, options...)
 
 	return zap.New(core)
 }
 
+// newScrubbedHub creates a hub with an empty scope. If scrubber is set, it processes all events sent with the hub,
+// so transactions and events captured with the hub directly don't leak the request and breadcrumbs data.
+func newScrubbedHub(client *sentry.Client, scrubber *Scrubber) *sentry.Hub {
+	hub := sentry.NewHub(client, sentry.NewScope())
+	if scrubber != nil {
+		hub.Scope().AddEventProcessor(scrubber.ProcessEvent)
+	}
+	return hub
+}
+
 func prepareOptions(core *SentryCore) []SentryCoreOption {
 	var options []SentryCoreOption
 	if breadcrumbLevel := core.BreadcrumbLevel; breadcrumbLevel != defaultBreadcrumbLevel {
@@ -156,5 +199,38 @@ func prepareOptions(core *SentryCore) []SentryCoreOption {
 	if eventLevel := core.EventLevel; eventLevel != defaultEventLevel {
 		options = append(options, EventLevel(eventLevel))
 	}
+	if !reflect.DeepEqual(core.UserTags, SentryUserTagMap{}) {
+		options = append(options, UserTags(core.UserTags))
+	}
+	if len(core.GenericTags) != 0 {
+		options = append(options, GenericTags(core.GenericTags...))
+	}
+	if len(core.TagAliases) != 0 {
+		options = append(options, TagAliases(core.TagAliases))
+	}
+	if core.FingerprintField != "" {
+		options = append(options, FingerprintField(core.FingerprintField))
+	}
+	if core.Fingerprinter != nil {
+		options = append(options, Fingerprinter(core.Fingerprinter))
+	}
+	if core.DefaultContext != "" {
+		options = append(options, FieldsAsContexts(core.DefaultContext))
+	}
+	if core.Logs != nil {
+		options = append(options, SentryLogs(core.Logs, core.LogLevel))
+	}
+	if core.Scrubber != nil {
+		options = append(options, DataScrubber(core.Scrubber))
+	}
+	if core.Limiter != nil {
+		options = append(options, EventRateLimit(core.Limiter))
+	}
+	if core.Queue != nil {
+		options = append(options, AsyncDelivery(core.Queue))
+	}
+	if flushTimeout := core.FlushTimeout; flushTimeout != defaultFlushTimeout {
+		options = append(options, FlushTimeout(flushTimeout))
+	}
 	return options
 }
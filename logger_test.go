@@ -3,6 +3,7 @@ package logger
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/getsentry/sentry-go"
@@ -10,6 +11,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 type TestLoggerSuite struct {
@@ -51,8 +53,8 @@ func (s *TestLoggerSuite) TearDownTest() {
 	_ = sentry.Init(sentry.ClientOptions{})
 }
 
-func (s *TestLoggerSuite) wrapHandler(handler http.HandlerFunc) http.Handler {
-	return RequestLogger(s.logger)(handler)
+func (s *TestLoggerSuite) wrapHandler(handler http.HandlerFunc, options ...RequestLoggerOption) http.Handler {
+	return RequestLogger(s.logger, options...)(handler)
 }
 
 func (s *TestLoggerSuite) TestLoggerShouldSendEventToSentryAndReturnEventID() {
@@ -74,6 +76,337 @@ func (s *TestLoggerSuite) TestLoggerShouldSendEventToSentryAndReturnEventID() {
 	s.EqualValues(w.Header().Get("X-Sentry-Id"), eventID)
 }
 
+func (s *TestLoggerSuite) TestLoggerEventIDHeaderOption() {
+	s.logger = zap.New(NewSentryCoreWrapper(zapcore.NewNopCore(), sentry.CurrentHub()))
+
+	eventID := sentry.EventID("<not valid>")
+	s.sendEventMock.Do(func(event *sentry.Event) {
+		eventID = event.EventID
+	})
+
+	handler := func(_ http.ResponseWriter, r *http.Request) {
+		Ctx(r.Context()).Error("test error")
+	}
+
+	s.Run("custom header", func() {
+		w := httptest.NewRecorder()
+		s.wrapHandler(handler, EventIDHeader("X-Event-Id")).
+			ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/foo", nil))
+
+		s.EqualValues(eventID, w.Header().Get("X-Event-Id"))
+		s.Empty(w.Header().Get("X-Sentry-Id"))
+	})
+
+	s.Run("disabled header", func() {
+		w := httptest.NewRecorder()
+		s.wrapHandler(handler, EventIDHeader("")).
+			ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/foo", nil))
+
+		s.Empty(w.Header().Get("X-Sentry-Id"))
+	})
+}
+
+func (s *TestLoggerSuite) TestAccessLogDefaults() {
+	core, logs := observer.New(zapcore.DebugLevel)
+	s.logger = zap.New(core)
+
+	wrappedHandler := s.wrapHandler(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	req := httptest.NewRequest("POST", "http://example.com/foo?bar=1", nil)
+	wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+	s.Require().Equal(1, logs.Len())
+	entry := logs.All()[0]
+	s.Equal(zapcore.DebugLevel, entry.Level)
+	s.Equal("-", entry.Message)
+
+	fields := entry.ContextMap()
+	s.Contains(fields, "duration")
+	s.EqualValues(http.StatusCreated, fields["status"])
+	s.EqualValues(2, fields["size"])
+	s.Equal("POST", fields["method"])
+	s.Equal("http://example.com/foo?bar=1", fields["url"])
+	s.Equal("192.0.2.1", fields["ip"])
+}
+
+func (s *TestLoggerSuite) TestAccessLogOptions() {
+	core, logs := observer.New(zapcore.DebugLevel)
+	s.logger = zap.New(core)
+
+	status := http.StatusOK
+	wrappedHandler := s.wrapHandler(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	},
+		AccessLogLevel(4, zapcore.WarnLevel),
+		AccessLogLevel(5, zapcore.ErrorLevel),
+		AccessLogMessage("request served"),
+		AccessLogFieldNames(AccessLogFields{Status: "http.status", Method: "http.method"}),
+		RequestIDGenerator(func() string { return "request-id" }),
+		TraceFieldNames(TraceFields{}),
+	)
+
+	for _, tt := range []struct {
+		status int
+		level  zapcore.Level
+	}{
+		{http.StatusOK, zapcore.DebugLevel},
+		{http.StatusNotFound, zapcore.WarnLevel},
+		{http.StatusBadGateway, zapcore.ErrorLevel},
+	} {
+		status = tt.status
+		wrappedHandler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com/foo", nil))
+
+		entries := logs.TakeAll()
+		s.Require().Len(entries, 1)
+		s.Equal(tt.level, entries[0].Level)
+		s.Equal("request served", entries[0].Message)
+		s.Equal(map[string]interface{}{
+			"http.status": int64(tt.status),
+			"http.method": "GET",
+			"request_id":  "request-id",
+		}, entries[0].ContextMap())
+	}
+}
+
+func (s *TestLoggerSuite) TestClientIP() {
+	core, logs := observer.New(zapcore.DebugLevel)
+	s.logger = zap.New(NewSentryCoreWrapper(core, sentry.CurrentHub()))
+
+	called := false
+	s.sendEventMock.Do(func(event *sentry.Event) {
+		called = true
+
+		s.Equal("2001:db8::1", event.User.IPAddress)
+	})
+
+	wrappedHandler := s.wrapHandler(func(_ http.ResponseWriter, r *http.Request) {
+		Ctx(r.Context()).Error("test error")
+	}, TrustedProxies("10.0.0.0/8"))
+
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	req.RemoteAddr = "10.0.0.1:8080"
+	req.Header.Set("X-Forwarded-For", "2001:db8::1, 10.0.0.2")
+	wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+	s.True(called)
+	entries := logs.FilterMessage("-").All()
+	s.Require().Len(entries, 1)
+	s.Equal("2001:db8::1", entries[0].ContextMap()["ip"])
+}
+
+func (s *TestLoggerSuite) TestUserTagsKeepClientIP() {
+	s.logger = zap.New(NewSentryCoreWrapper(
+		zapcore.NewNopCore(),
+		sentry.CurrentHub(),
+		UserTags(SentryUserTagMap{ID: "user_id"}),
+	))
+
+	called := false
+	s.sendEventMock.Do(func(event *sentry.Event) {
+		called = true
+
+		s.Equal("42", event.User.ID)
+		s.Equal("192.0.2.1", event.User.IPAddress)
+	})
+
+	wrappedHandler := s.wrapHandler(func(_ http.ResponseWriter, r *http.Request) {
+		Ctx(r.Context()).Error("test error", zap.Int("user_id", 42))
+	})
+
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+	s.True(called)
+}
+
+func (s *TestLoggerSuite) TestTracePropagation() {
+	s.logger = zap.New(NewSentryCoreWrapper(zapcore.NewNopCore(), sentry.CurrentHub()))
+
+	var span *sentry.Span
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		span = sentry.SpanFromContext(r.Context())
+	})
+
+	s.Run("traceparent", func() {
+		req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+		req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+		req.Header.Set("baggage", "sentry-trace_id=0af7651916cd43dd8448eb211c80319c,sentry-environment=prod")
+		s.wrapHandler(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+		s.Require().NotNil(span)
+		s.Equal("0af7651916cd43dd8448eb211c80319c", span.TraceID.String())
+		s.Equal("b7ad6b7169203331", span.ParentSpanID.String())
+		s.Contains(span.ToBaggage(), "sentry-environment=prod")
+	})
+
+	s.Run("custom propagators", func() {
+		req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+		req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+		s.wrapHandler(handler, RequestTracePropagators(SentryTracePropagator{})).ServeHTTP(httptest.NewRecorder(), req)
+
+		s.Require().NotNil(span)
+		s.NotEqual("0af7651916cd43dd8448eb211c80319c", span.TraceID.String())
+	})
+}
+
+func (s *TestLoggerSuite) TestTraceFields() {
+	core, logs := observer.New(zapcore.DebugLevel)
+	s.logger = zap.New(NewSentryCoreWrapper(core, sentry.CurrentHub()))
+
+	var requestSpan, childSpan *sentry.Span
+	handler := WithExtraFields(func(_ *http.Request) []zap.Field {
+		return []zap.Field{zap.String("extra", "value")}
+	})(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		requestSpan = sentry.SpanFromContext(r.Context())
+		Ctx(r.Context()).Info("request")
+		CtxSpan(r.Context()).Info("request span")
+
+		// fields bound by the handler should be kept by loggers of child spans
+		ctx := WithLogger(r.Context(), Ctx(r.Context()).With(zap.String("user", "alice")))
+		childSpan = sentry.StartSpan(ctx, "db.query")
+		defer childSpan.Finish()
+		CtxSpan(childSpan.Context()).Info("child span")
+	}))
+	wrappedHandler := RequestLogger(s.logger,
+		TraceFieldNames(TraceFields{TraceID: "trace", SpanID: "span"}),
+		RequestIDGenerator(func() string { return "request-id" }),
+	)(handler)
+
+	wrappedHandler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com/foo", nil))
+	s.Require().NotNil(requestSpan)
+	s.Require().NotNil(childSpan)
+
+	for _, tt := range []struct {
+		message string
+		span    *sentry.Span
+	}{
+		{"request", requestSpan},
+		{"request span", requestSpan},
+		{"child span", childSpan},
+		{"-", requestSpan},
+	} {
+		entries := logs.FilterMessage(tt.message).All()
+		s.Require().Len(entries, 1, tt.message)
+		fields := entries[0].ContextMap()
+		s.Len(entries[0].Context, len(fields), "fields should not be duplicated")
+		s.Equal(requestSpan.TraceID.String(), fields["trace"], tt.message)
+		s.Equal(tt.span.SpanID.String(), fields["span"], tt.message)
+		s.Equal("request-id", fields[RequestIDKey], tt.message)
+		if tt.message != "-" {
+			s.Equal("value", fields["extra"], tt.message)
+		}
+	}
+	s.Equal("alice", logs.FilterMessage("child span").All()[0].ContextMap()["user"])
+}
+
+func (s *TestLoggerSuite) TestRequestID() {
+	core, logs := observer.New(zapcore.DebugLevel)
+	s.logger = zap.New(NewSentryCoreWrapper(core, sentry.CurrentHub()))
+
+	var requestID string
+	s.sendEventMock.Do(func(event *sentry.Event) {
+		s.Equal(requestID, event.Tags[RequestIDKey])
+		s.NotContains(event.Extra, RequestIDKey)
+	}).Times(6)
+
+	handler := func(_ http.ResponseWriter, r *http.Request) {
+		requestID = RequestID(r.Context())
+		Ctx(r.Context()).Error("test error")
+	}
+
+	s.Run("generated", func() {
+		w := httptest.NewRecorder()
+		s.wrapHandler(handler).ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/foo", nil))
+
+		s.Len(requestID, 32)
+		s.Equal(requestID, w.Header().Get("X-Request-Id"))
+		for _, entry := range logs.TakeAll() {
+			s.Equal(requestID, entry.ContextMap()[RequestIDKey])
+		}
+	})
+
+	s.Run("from header", func() {
+		req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+		req.Header.Set("X-Trace-Id", "incoming-id")
+
+		w := httptest.NewRecorder()
+		s.wrapHandler(handler,
+			RequestIDHeader("X-Trace-Id"),
+			RequestIDGenerator(func() string { return "generated-id" }),
+		).ServeHTTP(w, req)
+
+		s.Equal("incoming-id", requestID)
+		s.Equal("incoming-id", w.Header().Get("X-Trace-Id"))
+		s.Empty(w.Header().Get("X-Request-Id"))
+	})
+
+	for name, incoming := range map[string]string{
+		"too long":      strings.Repeat("a", 129),
+		"space":         "incoming id",
+		"control chars": "incoming\x1b[31mid",
+		"non ascii":     "incoming-идентификатор",
+	} {
+		s.Run("invalid header/"+name, func() {
+			req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			req.Header.Set("X-Request-Id", incoming)
+
+			w := httptest.NewRecorder()
+			s.wrapHandler(handler, RequestIDGenerator(func() string { return "generated-id" })).ServeHTTP(w, req)
+
+			s.Equal("generated-id", requestID)
+			s.Equal("generated-id", w.Header().Get("X-Request-Id"))
+		})
+	}
+}
+
+func (s *TestLoggerSuite) TestTransactionName() {
+	s.logger = zap.New(NewSentryCoreWrapper(zapcore.NewNopCore(), sentry.CurrentHub()))
+
+	var transaction *sentry.Span
+	handler := func(_ http.ResponseWriter, r *http.Request) {
+		transaction = sentry.TransactionFromContext(r.Context())
+	}
+
+	s.Run("request path", func() {
+		s.wrapHandler(handler).
+			ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com/users/42", nil))
+
+		s.Require().NotNil(transaction)
+		s.Equal("GET /users/42", transaction.Name)
+		s.Equal(sentry.SourceURL, transaction.Source)
+	})
+
+	s.Run("route resolver", func() {
+		s.wrapHandler(handler, RouteResolver(func(_ *http.Request) string {
+			return "/users/{id}"
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com/users/42", nil))
+
+		s.Require().NotNil(transaction)
+		s.Equal("GET /users/{id}", transaction.Name)
+		s.Equal(sentry.SourceRoute, transaction.Source)
+	})
+}
+
+func (s *TestLoggerSuite) TestSkipRequests() {
+	core, logs := observer.New(zapcore.DebugLevel)
+	s.logger = zap.New(core)
+
+	wrappedHandler := s.wrapHandler(func(_ http.ResponseWriter, r *http.Request) {
+		Ctx(r.Context()).Info("handler log")
+		s.Nil(sentry.TransactionFromContext(r.Context()))
+	}, SkipRequests(func(r *http.Request) bool {
+		return r.URL.Path == "/healthz"
+	}))
+
+	wrappedHandler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com/healthz", nil))
+
+	s.Require().Equal(1, logs.Len())
+	s.Equal("handler log", logs.All()[0].Message)
+}
+
 func (s *TestLoggerSuite) TestLoggerWithInjectedExtraFields() {
 	s.logger = zap.New(NewSentryCoreWrapper(zapcore.NewNopCore(), sentry.CurrentHub()))
 
@@ -125,6 +458,31 @@ func (s *TestLoggerSuite) TestForkedLoggerShouldOnlyLogRelatedEvents() {
 	s.True(called)
 }
 
+func (s *TestLoggerSuite) TestScrubberProcessesEventsSentWithHub() {
+	s.logger = zap.New(NewSentryCoreWrapper(zapcore.NewNopCore(), sentry.CurrentHub(), DataScrubber(NewScrubber())))
+
+	s.sendEventMock.Do(func(event *sentry.Event) {
+		s.Equal("direct message", event.Message)
+		s.Require().NotNil(event.Request)
+		s.Equal("page=1&token=[Filtered]", event.Request.QueryString)
+		s.Require().Len(event.Breadcrumbs, 1)
+		s.Equal("user [Filtered] logged in", event.Breadcrumbs[0].Message)
+	}).Times(2)
+
+	handler := func(_ http.ResponseWriter, r *http.Request) {
+		Hub(r.Context()).AddBreadcrumb(&sentry.Breadcrumb{Message: "user john@example.com logged in"}, nil)
+		Hub(r.Context()).CaptureMessage("direct message")
+	}
+
+	s.wrapHandler(handler).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com/foo?page=1&token=secret", nil))
+
+	hub := ForkedLogger(s.logger).Core().(sentryCoreWrapper).SentryCore().hub
+	hub.AddBreadcrumb(&sentry.Breadcrumb{Message: "user john@example.com logged in"}, nil)
+	hub.Scope().SetRequest(httptest.NewRequest("GET", "http://example.com/foo?page=1&token=secret", nil))
+	hub.CaptureMessage("direct message")
+}
+
 func TestRequestLogger(t *testing.T) {
 	suite.Run(t, new(TestLoggerSuite))
 }
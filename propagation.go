@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	traceparentHeader = "traceparent"
+
+	sentryBaggagePrefix = "sentry-"
+)
+
+// traceparentPattern matches W3C traceparent header: version-trace_id-parent_id-flags.
+var traceparentPattern = regexp.MustCompile(`^([[:xdigit:]]{2})-([[:xdigit:]]{32})-([[:xdigit:]]{16})-([[:xdigit:]]{2})`)
+
+// Propagator reads trace context from incoming request headers and writes it to outgoing ones.
+type Propagator interface {
+	// Extract returns trace in sentry-trace format and Sentry baggage from the headers.
+	// Empty values are returned if the headers have no trace context known to the propagator.
+	Extract(header http.Header) (trace, baggage string)
+	// Inject adds trace context of the span to the headers.
+	Inject(span *sentry.Span, header http.Header)
+}
+
+// SentryTracePropagator propagates trace with the sentry-trace header.
+type SentryTracePropagator struct{}
+
+func (SentryTracePropagator) Extract(header http.Header) (string, string) {
+	return header.Get(sentry.SentryTraceHeader), ""
+}
+
+func (SentryTracePropagator) Inject(span *sentry.Span, header http.Header) {
+	header.Set(sentry.SentryTraceHeader, span.ToSentryTrace())
+}
+
+// TraceContextPropagator propagates trace with the W3C traceparent header.
+// Incoming not sampled flag is ignored, so sampling decision is made by this service,
+// and tracestate header is left as is.
+type TraceContextPropagator struct{}
+
+func (TraceContextPropagator) Extract(header http.Header) (string, string) {
+	m := traceparentPattern.FindStringSubmatch(strings.ToLower(header.Get(traceparentHeader)))
+	if m == nil || m[1] == "ff" || strings.Trim(m[2], "0") == "" || strings.Trim(m[3], "0") == "" {
+		return "", ""
+	}
+
+	trace := m[2] + "-" + m[3]
+	if flags, _ := strconv.ParseUint(m[4], 16, 8); flags&1 == 1 {
+		trace += "-1"
+	}
+	return trace, ""
+}
+
+func (TraceContextPropagator) Inject(span *sentry.Span, header http.Header) {
+	flags := "00"
+	if span.Sampled.Bool() {
+		flags = "01"
+	}
+	header.Set(traceparentHeader, fmt.Sprintf("00-%s-%s-%s", span.TraceID.Hex(), span.SpanID.Hex(), flags))
+}
+
+// BaggagePropagator propagates Sentry dynamic sampling context with the baggage header.
+// Baggage members not related to Sentry are kept in outgoing requests.
+type BaggagePropagator struct{}
+
+func (BaggagePropagator) Extract(header http.Header) (string, string) {
+	return "", strings.Join(header.Values(sentry.SentryBaggageHeader), ",")
+}
+
+func (BaggagePropagator) Inject(span *sentry.Span, header http.Header) {
+	baggage := span.ToBaggage()
+	if baggage == "" {
+		return
+	}
+
+	var members []string
+	for _, value := range header.Values(sentry.SentryBaggageHeader) {
+		for _, member := range strings.Split(value, ",") {
+			member = strings.TrimSpace(member)
+			if member != "" && !strings.HasPrefix(member, sentryBaggagePrefix) {
+				members = append(members, member)
+			}
+		}
+	}
+	header.Set(sentry.SentryBaggageHeader, strings.Join(append(members, baggage), ","))
+}
+
+//nolint:gochecknoglobals
+var defaultPropagators = []Propagator{SentryTracePropagator{}, TraceContextPropagator{}, BaggagePropagator{}}
+
+// continueFromHeaders returns span option continuing the trace extracted by the first propagator
+// which found it in the headers.
+func continueFromHeaders(propagators []Propagator, header http.Header) sentry.SpanOption {
+	var trace, baggage string
+	for _, propagator := range propagators {
+		t, b := propagator.Extract(header)
+		if trace == "" {
+			trace = t
+		}
+		if baggage == "" {
+			baggage = b
+		}
+	}
+	return sentry.ContinueFromHeaders(trace, baggage)
+}
+
+// injectHeaders adds trace context of the span to the headers with all propagators.
+func injectHeaders(propagators []Propagator, span *sentry.Span, header http.Header) {
+	for _, propagator := range propagators {
+		propagator.Inject(span, header)
+	}
+}
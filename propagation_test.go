@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testTraceID = "0af7651916cd43dd8448eb211c80319c"
+	testSpanID  = "b7ad6b7169203331"
+)
+
+func TestTraceContextPropagatorExtract(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		traceparent string
+		trace       string
+	}{
+		{"sampled", "00-" + testTraceID + "-" + testSpanID + "-01", testTraceID + "-" + testSpanID + "-1"},
+		{"not sampled", "00-" + testTraceID + "-" + testSpanID + "-00", testTraceID + "-" + testSpanID},
+		{"upper case", "00-0AF7651916CD43DD8448EB211C80319C-" + testSpanID + "-01", testTraceID + "-" + testSpanID + "-1"},
+		{"invalid version", "ff-" + testTraceID + "-" + testSpanID + "-01", ""},
+		{"zero trace id", "00-00000000000000000000000000000000-" + testSpanID + "-01", ""},
+		{"zero span id", "00-" + testTraceID + "-0000000000000000-01", ""},
+		{"malformed", "00-" + testTraceID, ""},
+		{"missing", "", ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			header := http.Header{}
+			if tt.traceparent != "" {
+				header.Set("traceparent", tt.traceparent)
+			}
+			trace, baggage := TraceContextPropagator{}.Extract(header)
+			assert.Equal(t, tt.trace, trace)
+			assert.Empty(t, baggage)
+		})
+	}
+}
+
+func TestTraceContextPropagatorInject(t *testing.T) {
+	t.Parallel()
+
+	span := sentry.StartSpan(context.Background(), "test", sentry.ContinueFromTrace(testTraceID+"-"+testSpanID))
+	span.Sampled = sentry.SampledTrue
+	header := http.Header{}
+	TraceContextPropagator{}.Inject(span, header)
+	assert.Equal(t, "00-"+testTraceID+"-"+span.SpanID.String()+"-01", header.Get("traceparent"))
+
+	span.Sampled = sentry.SampledFalse
+	TraceContextPropagator{}.Inject(span, header)
+	assert.Equal(t, "00-"+testTraceID+"-"+span.SpanID.String()+"-00", header.Get("traceparent"))
+}
+
+func TestBaggagePropagatorInject(t *testing.T) {
+	t.Parallel()
+
+	span := sentry.StartSpan(context.Background(), "test",
+		sentry.ContinueFromHeaders(testTraceID+"-"+testSpanID+"-1", "sentry-trace_id="+testTraceID+",sentry-sample_rate=1"))
+
+	header := http.Header{}
+	header.Set("baggage", "userId=alice, sentry-trace_id=other")
+	BaggagePropagator{}.Inject(span, header)
+
+	baggage := header.Get("baggage")
+	assert.Contains(t, baggage, "userId=alice")
+	assert.Contains(t, baggage, "sentry-trace_id="+testTraceID)
+	assert.Contains(t, baggage, "sentry-sample_rate=1")
+	assert.NotContains(t, baggage, "sentry-trace_id=other")
+}
+
+func TestContinueFromHeaders(t *testing.T) {
+	t.Parallel()
+
+	t.Run("first propagator wins", func(t *testing.T) {
+		t.Parallel()
+
+		header := http.Header{}
+		header.Set("sentry-trace", testTraceID+"-"+testSpanID+"-1")
+		header.Set("traceparent", "00-11111111111111111111111111111111-2222222222222222-01")
+
+		span := sentry.StartSpan(context.Background(), "test", continueFromHeaders(defaultPropagators, header))
+		assert.Equal(t, testTraceID, span.TraceID.String())
+		assert.Equal(t, testSpanID, span.ParentSpanID.String())
+	})
+
+	t.Run("traceparent", func(t *testing.T) {
+		t.Parallel()
+
+		header := http.Header{}
+		header.Set("traceparent", "00-"+testTraceID+"-"+testSpanID+"-01")
+		header.Set("baggage", "sentry-trace_id="+testTraceID+",sentry-environment=prod")
+
+		span := sentry.StartSpan(context.Background(), "test", continueFromHeaders(defaultPropagators, header))
+		assert.Equal(t, testTraceID, span.TraceID.String())
+		assert.Equal(t, testSpanID, span.ParentSpanID.String())
+		assert.Contains(t, span.ToBaggage(), "sentry-environment=prod")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		header := http.Header{}
+		header.Set("traceparent", "00-"+testTraceID+"-"+testSpanID+"-01")
+
+		span := sentry.StartSpan(context.Background(), "test", continueFromHeaders(nil, header))
+		assert.NotEqual(t, testTraceID, span.TraceID.String())
+	})
+}
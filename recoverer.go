@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+)
+
+// Recoverer is a middleware for recovering from panics in handlers wrapped with RequestLogger.
+// Recovered panic is logged with DPanic level through the logger from request context, so it will be sent
+// to Sentry as a crashed exception event with request breadcrumbs and stacktrace. Unlike Panic and Fatal levels,
+// DPanic doesn't flush the hub, so the request isn't blocked until the event is delivered. Span status of the current
+// request will be set to internal_error and 500 status code will be written if headers were not sent yet.
+// If repanicOnAbort is true, http.ErrAbortHandler will be re-panicked without reporting so net/http can abort
+// the response.
+func Recoverer(repanicOnAbort bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww, ok := w.(WrapResponseWriter)
+			if !ok {
+				ww = NewWrapResponseWriter(w, r.ProtoMajor)
+			}
+
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+				//nolint:errorlint,goerr113
+				if rvr == http.ErrAbortHandler && repanicOnAbort {
+					panic(rvr)
+				}
+
+				ctx := r.Context()
+				if span := sentry.SpanFromContext(ctx); span != nil {
+					span.Status = sentry.SpanStatusInternalError
+				}
+
+				Ctx(ctx).DPanic("panic recovered", zap.Error(panicError(rvr)))
+
+				// WriteHeader is no-op if headers were already sent
+				ww.WriteHeader(http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+func panicError(rvr interface{}) error {
+	if err, ok := rvr.(error); ok {
+		return err
+	}
+	return errors.New(fmt.Sprint(rvr)) //nolint:goerr113
+}
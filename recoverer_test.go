@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type RecovererSuite struct {
+	suite.Suite
+
+	ctrl          *gomock.Controller
+	sendEventMock *gomock.Call
+
+	logger *zap.Logger
+}
+
+func (s *RecovererSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+
+	transportMock := NewMockTransport(s.ctrl)
+	transportMock.EXPECT().
+		Configure(gomock.AssignableToTypeOf(sentry.ClientOptions{})).
+		Return()
+	s.sendEventMock = transportMock.EXPECT().
+		SendEvent(gomock.AssignableToTypeOf(&sentry.Event{})).
+		Return().
+		MinTimes(0)
+	transportMock.EXPECT().
+		Flush(gomock.Any()).
+		Return(true).
+		MinTimes(0)
+
+	_ = sentry.Init(sentry.ClientOptions{
+		Transport: transportMock,
+	})
+
+	s.logger = zap.New(NewSentryCoreWrapper(zapcore.NewNopCore(), sentry.CurrentHub()))
+}
+
+func (s *RecovererSuite) TearDownTest() {
+	s.ctrl.Finish()
+
+	// reset sentry client to default
+	_ = sentry.Init(sentry.ClientOptions{})
+}
+
+func (s *RecovererSuite) wrapHandler(handler http.HandlerFunc, repanicOnAbort bool) http.Handler {
+	return RequestLogger(s.logger)(Recoverer(repanicOnAbort)(handler))
+}
+
+func (s *RecovererSuite) TestPanicIsReported() {
+	eventID := sentry.EventID("<not valid>")
+	s.sendEventMock.Do(func(event *sentry.Event) {
+		eventID = event.EventID
+
+		s.Equal("panic recovered", event.Message)
+		s.Equal(sentry.LevelError, event.Level)
+
+		s.Require().Len(event.Exception, 1)
+		s.Equal("something went wrong", event.Exception[0].Value)
+		s.NotNil(event.Exception[0].Stacktrace)
+
+		s.Require().Len(event.Threads, 1)
+		s.True(event.Threads[0].Crashed)
+
+		s.Require().Len(event.Breadcrumbs, 1)
+		s.Equal("before panic", event.Breadcrumbs[0].Message)
+	}).Times(1)
+
+	var span *sentry.Span
+	wrappedHandler := s.wrapHandler(func(_ http.ResponseWriter, r *http.Request) {
+		span = sentry.SpanFromContext(r.Context())
+		Ctx(r.Context()).Debug("before panic")
+		panic("something went wrong")
+	}, false)
+
+	w := httptest.NewRecorder()
+	s.NotPanics(func() {
+		wrappedHandler.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/foo", nil))
+	})
+
+	s.Equal(http.StatusInternalServerError, w.Code)
+	s.EqualValues(eventID, w.Header().Get("X-Sentry-Id"))
+	s.Require().NotNil(span)
+	s.Equal(sentry.SpanStatusInternalError, span.Status)
+}
+
+func (s *RecovererSuite) TestPanicAfterHeadersWereSent() {
+	s.sendEventMock.Times(1)
+
+	wrappedHandler := s.wrapHandler(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic(http.ErrBodyNotAllowed)
+	}, true)
+
+	w := httptest.NewRecorder()
+	wrappedHandler.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/foo", nil))
+
+	s.Equal(http.StatusAccepted, w.Code)
+}
+
+func (s *RecovererSuite) TestAbortHandler() {
+	s.Run("repanic", func() {
+		s.sendEventMock.Times(0)
+
+		wrappedHandler := s.wrapHandler(func(_ http.ResponseWriter, _ *http.Request) {
+			panic(http.ErrAbortHandler)
+		}, true)
+
+		s.PanicsWithValue(http.ErrAbortHandler, func() {
+			wrappedHandler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com/foo", nil))
+		})
+	})
+
+	s.Run("recover", func() {
+		s.sendEventMock.Times(1)
+
+		wrappedHandler := s.wrapHandler(func(_ http.ResponseWriter, _ *http.Request) {
+			panic(http.ErrAbortHandler)
+		}, false)
+
+		w := httptest.NewRecorder()
+		wrappedHandler.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/foo", nil))
+
+		s.Equal(http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestRecoverer(t *testing.T) {
+	suite.Run(t, new(RecovererSuite))
+}
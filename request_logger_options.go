@@ -0,0 +1,300 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultAccessLogLevel   = zapcore.DebugLevel
+	defaultAccessLogMessage = "-"
+	defaultRequestIDHeader  = "X-Request-Id"
+	maxRequestIDLength      = 128
+
+	// RequestIDKey is a name of the logger field and Sentry tag with request id.
+	RequestIDKey = "request_id"
+)
+
+// AccessLogFields maps access log values to field names. Values with an empty field name are omitted.
+type AccessLogFields struct {
+	Duration string
+	Status   string
+	Size     string
+	Method   string
+	URL      string
+	IP       string
+}
+
+//nolint:gochecknoglobals
+var defaultAccessLogFields = AccessLogFields{
+	Duration: "duration",
+	Status:   "status",
+	Size:     "size",
+	Method:   "method",
+	URL:      "url",
+	IP:       "ip",
+}
+
+// TraceFields maps trace identifiers of the current span to field names. Identifiers with an empty field name are omitted.
+type TraceFields struct {
+	TraceID string
+	SpanID  string
+}
+
+//nolint:gochecknoglobals
+var defaultTraceFields = TraceFields{
+	TraceID: "trace_id",
+	SpanID:  "span_id",
+}
+
+// fields returns logger fields with identifiers of the span.
+func (f TraceFields) fields(span *sentry.Span) []zapcore.Field {
+	if span == nil {
+		return nil
+	}
+
+	var fields []zapcore.Field
+	if f.TraceID != "" {
+		fields = append(fields, zap.String(f.TraceID, span.TraceID.String()))
+	}
+	if f.SpanID != "" {
+		fields = append(fields, zap.String(f.SpanID, span.SpanID.String()))
+	}
+	return fields
+}
+
+type requestLoggerConfig struct {
+	accessLogLevels  map[int]zapcore.Level
+	accessLogMessage string
+	accessLogFields  AccessLogFields
+
+	eventIDHeader string
+	skip          func(r *http.Request) bool
+
+	clientIP *ClientIPResolver
+
+	requestIDHeader    string
+	requestIDGenerator func() string
+
+	routeResolver func(r *http.Request) string
+
+	traceFields TraceFields
+
+	propagators []Propagator
+}
+
+type RequestLoggerOption func(*requestLoggerConfig)
+
+func newRequestLoggerConfig(options []RequestLoggerOption) *requestLoggerConfig {
+	config := &requestLoggerConfig{
+		accessLogLevels:  make(map[int]zapcore.Level),
+		accessLogMessage: defaultAccessLogMessage,
+		accessLogFields:  defaultAccessLogFields,
+		eventIDHeader:    sentryEventIDHeader,
+		clientIP:         &ClientIPResolver{},
+
+		requestIDHeader:    defaultRequestIDHeader,
+		requestIDGenerator: generateRequestID,
+
+		traceFields: defaultTraceFields,
+		propagators: defaultPropagators,
+	}
+
+	for _, option := range options {
+		option(config)
+	}
+
+	return config
+}
+
+// accessLogLevel returns level of access log message for provided response status.
+func (c *requestLoggerConfig) accessLogLevel(status int) zapcore.Level {
+	if status == 0 {
+		// nothing was written so net/http will respond with 200
+		status = http.StatusOK
+	}
+	if level, ok := c.accessLogLevels[status/100]; ok {
+		return level
+	}
+	return defaultAccessLogLevel
+}
+
+// requestID returns id of the request from the request header or generates a new one.
+// Incoming id is ignored if it isn't valid, so clients can't inject arbitrary data into logs and responses.
+func (c *requestLoggerConfig) requestID(r *http.Request) string {
+	if c.requestIDHeader != "" {
+		if requestID := r.Header.Get(c.requestIDHeader); validRequestID(requestID) {
+			return requestID
+		}
+	}
+	return c.requestIDGenerator()
+}
+
+// validRequestID reports whether id is non-empty, not longer than maxRequestIDLength
+// and consists of visible ASCII characters only.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// routeTransactionName returns Sentry transaction name based on the route pattern matched the request.
+// Returns empty string if pattern is unknown.
+func (c *requestLoggerConfig) routeTransactionName(r *http.Request) string {
+	var pattern string
+	if c.routeResolver != nil {
+		pattern = c.routeResolver(r)
+	}
+	if pattern == "" {
+		pattern = requestPattern(r)
+	}
+	if pattern == "" {
+		return ""
+	}
+
+	// http.ServeMux patterns could already contain method
+	if strings.Contains(pattern, " ") {
+		return pattern
+	}
+	return fmt.Sprintf("%s %s", r.Method, pattern)
+}
+
+// accessLogEntryFields returns fields of access log message for provided request.
+func (c *requestLoggerConfig) accessLogEntryFields(
+	r *http.Request, ww WrapResponseWriter, duration time.Duration, clientIP string,
+) []zap.Field {
+	names := c.accessLogFields
+	fields := make([]zap.Field, 0, 6) //nolint:gomnd
+	if names.Duration != "" {
+		fields = append(fields, zap.Duration(names.Duration, duration))
+	}
+	if names.Status != "" {
+		fields = append(fields, zap.Int(names.Status, ww.Status()))
+	}
+	if names.Size != "" {
+		fields = append(fields, zap.Int(names.Size, ww.BytesWritten()))
+	}
+	if names.Method != "" {
+		fields = append(fields, zap.String(names.Method, r.Method))
+	}
+	if names.URL != "" {
+		fields = append(fields, zap.String(names.URL, r.URL.String()))
+	}
+	if names.IP != "" {
+		fields = append(fields, zap.String(names.IP, clientIP))
+	}
+	return fields
+}
+
+// AccessLogLevel will set a level of access log messages for responses with provided status class
+// (e.g. 5 for 5xx responses). Debug level is used for classes without explicitly set level.
+func AccessLogLevel(statusClass int, level zapcore.Level) RequestLoggerOption {
+	return func(c *requestLoggerConfig) {
+		c.accessLogLevels[statusClass] = level
+	}
+}
+
+// AccessLogMessage will set a message of access log entries.
+func AccessLogMessage(message string) RequestLoggerOption {
+	return func(c *requestLoggerConfig) {
+		c.accessLogMessage = message
+	}
+}
+
+// AccessLogFieldNames will set field names of access log entries. Fields with empty names will not be logged.
+func AccessLogFieldNames(fields AccessLogFields) RequestLoggerOption {
+	return func(c *requestLoggerConfig) {
+		c.accessLogFields = fields
+	}
+}
+
+// TraceFieldNames will set names of request logger fields with trace and span ids of the request span.
+// Fields with empty names will not be logged.
+func TraceFieldNames(fields TraceFields) RequestLoggerOption {
+	return func(c *requestLoggerConfig) {
+		c.traceFields = fields
+	}
+}
+
+// RequestTracePropagators will set propagators used to continue a trace from incoming request headers.
+// The trace is taken from the first propagator which found it. By default sentry-trace,
+// W3C traceparent and baggage headers are read. No propagators disables continuing traces.
+func RequestTracePropagators(propagators ...Propagator) RequestLoggerOption {
+	return func(c *requestLoggerConfig) {
+		c.propagators = propagators
+	}
+}
+
+// EventIDHeader will set a name of response header with the id of the last Sentry event.
+// Empty name disables the header.
+func EventIDHeader(name string) RequestLoggerOption {
+	return func(c *requestLoggerConfig) {
+		c.eventIDHeader = name
+	}
+}
+
+// SkipRequests will disable access log and Sentry transaction for requests matching provided predicate
+// (e.g. health checks). Logger will still be injected into the request context.
+func SkipRequests(skip func(r *http.Request) bool) RequestLoggerOption {
+	return func(c *requestLoggerConfig) {
+		c.skip = skip
+	}
+}
+
+// TrustedProxies will enable resolving client IP address from Forwarded, X-Forwarded-For and X-Real-IP headers
+// for requests coming from provided networks in CIDR notation. Panics if any of networks is invalid.
+func TrustedProxies(networks ...string) RequestLoggerOption {
+	resolver, err := NewClientIPResolver(networks...)
+	if err != nil {
+		panic(err)
+	}
+	return func(c *requestLoggerConfig) {
+		c.clientIP = resolver
+	}
+}
+
+// RequestIDHeader will set a name of the header to read incoming request id from and to return it in response.
+// Empty name disables both, so request id will always be generated. Incoming ids longer than 128 characters
+// or containing anything but visible ASCII characters are replaced with generated ones.
+func RequestIDHeader(name string) RequestLoggerOption {
+	return func(c *requestLoggerConfig) {
+		c.requestIDHeader = name
+	}
+}
+
+// RequestIDGenerator will set a function to generate request id if it is absent in the request.
+func RequestIDGenerator(generator func() string) RequestLoggerOption {
+	return func(c *requestLoggerConfig) {
+		c.requestIDGenerator = generator
+	}
+}
+
+func generateRequestID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// RouteResolver will set a function returning the route pattern matched the request (e.g. "/users/{id}")
+// to name Sentry transactions. It is called after the request was served, so routers storing
+// a route in the request context can be used. If resolver is not set or returns an empty string
+// http.ServeMux pattern is used (Go 1.23+), and then the request path.
+func RouteResolver(resolver func(r *http.Request) string) RequestLoggerOption {
+	return func(c *requestLoggerConfig) {
+		c.routeResolver = resolver
+	}
+}
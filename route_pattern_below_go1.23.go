@@ -0,0 +1,11 @@
+//go:build !go1.23
+
+package logger
+
+import "net/http"
+
+// requestPattern returns the pattern of http.ServeMux route matched the request.
+// Patterns are not exposed by http.ServeMux before Go 1.23.
+func requestPattern(_ *http.Request) string {
+	return ""
+}
@@ -0,0 +1,10 @@
+//go:build go1.23
+
+package logger
+
+import "net/http"
+
+// requestPattern returns the pattern of http.ServeMux route matched the request.
+func requestPattern(r *http.Request) string {
+	return r.Pattern
+}
@@ -0,0 +1,53 @@
+//go:build go1.23
+
+// module requires an older Go version, so http.ServeMux patterns should be enabled explicitly
+//go:debug httpmuxgo121=0
+
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestServeMuxTransactionName(t *testing.T) {
+	client, err := sentry.NewClient(sentry.ClientOptions{})
+	require.NoError(t, err)
+	hub := sentry.NewHub(client, sentry.NewScope())
+	logger := zap.New(NewSentryCoreWrapper(zapcore.NewNopCore(), hub))
+
+	var transaction *sentry.Span
+	handler := func(_ http.ResponseWriter, r *http.Request) {
+		transaction = sentry.TransactionFromContext(r.Context())
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", handler)
+	mux.HandleFunc("/posts/{id}", handler)
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"http://example.com/users/42", "GET /users/{id}"},
+		{"http://example.com/posts/42", "GET /posts/{id}"},
+	}
+
+	for _, tt := range tests {
+		//nolint:scopelint
+		t.Run(tt.url, func(t *testing.T) {
+			RequestLogger(logger)(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.url, nil))
+
+			require.NotNil(t, transaction)
+			assert.Equal(t, tt.want, transaction.Name)
+			assert.Equal(t, sentry.SourceRoute, transaction.Source)
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const defaultScrubberPlaceholder = "[Filtered]"
+
+// cardNumberPattern matches candidates for credit card numbers, only ones passing Luhn check are scrubbed,
+// so timestamps and other long numeric ids are kept.
+var cardNumberPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+
+// Scrubber replaces sensitive data in events, breadcrumbs and extras sent to Sentry.
+type Scrubber struct {
+	// Keys is a list of case-insensitive substrings of sensitive key names.
+	// Values of such keys (fields, headers, query parameters) are replaced as a whole.
+	Keys []string
+	// Patterns are matched against string values, only matched parts are replaced.
+	// Matches of the default credit card pattern are replaced only if they pass Luhn check.
+	Patterns []*regexp.Regexp
+	// Placeholder is a replacement for scrubbed values.
+	Placeholder string
+}
+
+// NewScrubber creates Scrubber with default denylist of key names and patterns for
+// credit card numbers, JWTs and emails.
+func NewScrubber() *Scrubber {
+	return &Scrubber{
+		Keys: []string{
+			"password", "passwd", "secret", "token", "api_key", "apikey", "authorization",
+			"cookie", "session", "credit_card", "card_number", "cvv", "private_key",
+		},
+		Patterns: []*regexp.Regexp{
+			cardNumberPattern,
+			regexp.MustCompile(`\beyJ[\w-]+\.[\w-]+\.[\w-]*`),
+			regexp.MustCompile(`[\w.%+-]+@[\w.-]+\.[A-Za-z]{2,}`),
+		},
+		Placeholder: defaultScrubberPlaceholder,
+	}
+}
+
+// IsSensitiveKey reports whether values of provided key should be scrubbed.
+func (s *Scrubber) IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitiveKey := range s.Keys {
+		if strings.Contains(key, strings.ToLower(sensitiveKey)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScrubString replaces parts of the string matching any of patterns.
+func (s *Scrubber) ScrubString(value string) string {
+	for _, pattern := range s.Patterns {
+		if pattern == cardNumberPattern {
+			value = pattern.ReplaceAllStringFunc(value, func(match string) string {
+				if !luhnValid(match) {
+					return match
+				}
+				return s.Placeholder
+			})
+			continue
+		}
+		value = pattern.ReplaceAllLiteralString(value, s.Placeholder)
+	}
+	return value
+}
+
+// luhnValid reports whether digits of the number (separators are skipped) pass Luhn checksum.
+func luhnValid(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		if number[i] < '0' || number[i] > '9' {
+			continue
+		}
+		digit := int(number[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// ScrubURL replaces values of sensitive query parameters and password of user info.
+// Other query parameters are checked against patterns.
+func (s *Scrubber) ScrubURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return s.ScrubString(rawURL)
+	}
+
+	u.RawQuery = s.ScrubQuery(u.RawQuery)
+
+	if _, hasPassword := u.User.Password(); hasPassword {
+		// placeholder is inserted after encoding to keep it readable
+		userInfo := u.User.String()
+		username := url.User(u.User.Username()).String()
+		return strings.Replace(u.String(), userInfo+"@", username+":"+s.Placeholder+"@", 1)
+	}
+	return u.String()
+}
+
+// ScrubQuery replaces values of sensitive parameters in URL query string.
+func (s *Scrubber) ScrubQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, value, found := strings.Cut(param, "=")
+		if !found {
+			continue
+		}
+
+		unescapedKey, err := url.QueryUnescape(key)
+		if err != nil {
+			unescapedKey = key
+		}
+		if s.IsSensitiveKey(unescapedKey) {
+			params[i] = key + "=" + s.Placeholder
+			continue
+		}
+
+		if unescapedValue, err := url.QueryUnescape(value); err == nil {
+			if scrubbed := s.ScrubString(unescapedValue); scrubbed != unescapedValue {
+				escaped := url.QueryEscape(scrubbed)
+				params[i] = key + "=" + strings.ReplaceAll(escaped, url.QueryEscape(s.Placeholder), s.Placeholder)
+			}
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// ScrubMap returns a copy of provided map with scrubbed values. Nested maps, slices and structs are traversed.
+func (s *Scrubber) ScrubMap(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+
+	scrubbed := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		scrubbed[key] = s.ScrubValue(key, value)
+	}
+	return scrubbed
+}
+
+// ScrubValue returns scrubbed copy of the value stored with provided key.
+func (s *Scrubber) ScrubValue(key string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	if s.IsSensitiveKey(key) {
+		return s.Placeholder
+	}
+
+	switch typed := value.(type) {
+	case string:
+		if key == BreadcrumbDataURL {
+			return s.ScrubURL(typed)
+		}
+		return s.ScrubString(typed)
+	case map[string]interface{}:
+		return s.ScrubMap(typed)
+	case []interface{}:
+		scrubbed := make([]interface{}, len(typed))
+		for i, item := range typed {
+			scrubbed[i] = s.ScrubValue("", item)
+		}
+		return scrubbed
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() { //nolint:exhaustive
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		// Values will be sent to Sentry as JSON anyway, so JSON representation could be traversed instead
+		var generic interface{}
+		data, err := json.Marshal(value)
+		if err != nil || json.Unmarshal(data, &generic) != nil {
+			return value
+		}
+		if generic == nil {
+			return value
+		}
+		return s.ScrubValue(key, generic)
+	default:
+		return value
+	}
+}
+
+// ProcessEvent is a sentry.EventProcessor scrubbing message, exceptions, extras, contexts,
+// breadcrumbs and request data of the event.
+func (s *Scrubber) ProcessEvent(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+	event.Message = s.ScrubString(event.Message)
+	for i := range event.Exception {
+		event.Exception[i].Value = s.ScrubString(event.Exception[i].Value)
+	}
+
+	event.Extra = s.ScrubMap(event.Extra)
+	for name, context := range event.Contexts {
+		if name == "trace" {
+			continue
+		}
+		event.Contexts[name] = s.ScrubMap(context)
+	}
+
+	for i, breadcrumb := range event.Breadcrumbs {
+		// breadcrumbs are shared with the scope, so they should be copied
+		scrubbed := *breadcrumb
+		scrubbed.Message = s.ScrubString(breadcrumb.Message)
+		scrubbed.Data = s.ScrubMap(breadcrumb.Data)
+		event.Breadcrumbs[i] = &scrubbed
+	}
+
+	if event.Request != nil {
+		s.scrubRequest(event.Request)
+	}
+
+	return event
+}
+
+func (s *Scrubber) scrubRequest(request *sentry.Request) {
+	request.URL = s.ScrubURL(request.URL)
+	request.QueryString = s.ScrubQuery(request.QueryString)
+	request.Data = s.ScrubString(request.Data)
+	if request.Cookies != "" {
+		request.Cookies = s.Placeholder
+	}
+
+	headers := make(map[string]string, len(request.Headers))
+	for key, value := range request.Headers {
+		if s.IsSensitiveKey(key) {
+			headers[key] = s.Placeholder
+		} else {
+			headers[key] = s.ScrubString(value)
+		}
+	}
+	request.Headers = headers
+}
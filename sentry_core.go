@@ -3,6 +3,7 @@ package logger
 import (
 	"fmt"
 	"reflect"
+	"sync/atomic"
 	"time"
 
 	"github.com/getsentry/sentry-go"
@@ -12,9 +13,15 @@ import (
 const (
 	defaultBreadcrumbLevel = zapcore.DebugLevel
 	defaultEventLevel      = zapcore.ErrorLevel
+	defaultFlushTimeout    = 30 * time.Second
+
+	// trace context is managed by sentry-go, so zap groups with this name are not mapped to contexts.
+	traceContextKey = "trace"
 )
 
 // SentryUserTagMap maps field names which will be passed to sentry as User.
+// Nested fields could be referenced by dotted path, e.g. "user.id".
+// Logged values are merged with the user of the scope, fields without value are not changed.
 type SentryUserTagMap struct {
 	ID        string
 	IPAddress string
@@ -22,19 +29,46 @@ type SentryUserTagMap struct {
 	Username  string
 	Email     string
 	Segment   string
+	// Data maps keys of User.Data to field names.
+	Data map[string]string
 }
 
 type SentryCore struct {
 	zapcore.LevelEnabler
 
-	hub   *sentry.Hub
-	scope *sentry.Scope
+	hub         *sentry.Hub
+	lastEventID *atomic.Value
+	// onEvent is called with ID of every event sent by the core, it is used by RequestLogger
+	onEvent func(eventID sentry.EventID)
 
 	BreadcrumbLevel zapcore.Level
 	EventLevel      zapcore.Level
+	// LogLevel is a minimum level of entries sent to Sentry Logs, it is used only if Logs is set.
+	LogLevel zapcore.Level
+	Logs     *LogBatcher
 
 	UserTags    SentryUserTagMap
 	GenericTags []string
+	// TagAliases maps field paths to tag names, such fields are passed as tags even if not listed in GenericTags.
+	TagAliases map[string]string
+
+	// FingerprintField is a name of zap field with event fingerprint, it takes precedence over Fingerprinter.
+	FingerprintField string
+	Fingerprinter    FingerprintFunc
+
+	Scrubber *Scrubber
+	Limiter  *EventLimiter
+
+	// DefaultContext is a name of Sentry context for fields which are not groups.
+	// Fields are passed to Sentry as extra if it is empty.
+	DefaultContext string
+
+	// Queue is used to deliver events asynchronously, events are sent synchronously if it is nil.
+	Queue        *EventQueue
+	FlushTimeout time.Duration
+
+	// fields added by With, they are mapped to the event together with fields of the entry
+	fields map[string]interface{}
 }
 
 type SentryCoreOption func(*SentryCore)
@@ -57,6 +91,14 @@ func EventLevel(level zapcore.Level) SentryCoreOption {
 	}
 }
 
+// SentryLogs will set LogBatcher to send entries with level or higher to Sentry Logs.
+func SentryLogs(batcher *LogBatcher, level zapcore.Level) SentryCoreOption {
+	return func(w *SentryCore) {
+		w.Logs = batcher
+		w.LogLevel = level
+	}
+}
+
 // UserTags will set map to match zap fields with sentry user tags.
 func UserTags(tagMap SentryUserTagMap) SentryCoreOption {
 	return func(w *SentryCore) {
@@ -65,59 +107,151 @@ func UserTags(tagMap SentryUserTagMap) SentryCoreOption {
 }
 
 // GenericTags defines which zap fields should be passed as tags to Sentry.
+// Nested fields could be referenced by dotted path (e.g. "http.method") or glob pattern (e.g. "http.*").
 func GenericTags(tags ...string) SentryCoreOption {
 	return func(w *SentryCore) {
 		w.GenericTags = tags
 	}
 }
 
+// TagAliases will set names of tags for fields, e.g. {"user.org.id": "org_id"}.
+func TagAliases(aliases map[string]string) SentryCoreOption {
+	return func(w *SentryCore) {
+		w.TagAliases = aliases
+	}
+}
+
+// FingerprintField will set a name of zap field which value (string or list of strings) will be used
+// as event fingerprint. This field will not be passed as extra.
+func FingerprintField(name string) SentryCoreOption {
+	return func(w *SentryCore) {
+		w.FingerprintField = name
+	}
+}
+
+// Fingerprinter will set a function to compute event fingerprints, e.g. FingerprintByMessageAndRootError.
+func Fingerprinter(fingerprinter FingerprintFunc) SentryCoreOption {
+	return func(w *SentryCore) {
+		w.Fingerprinter = fingerprinter
+	}
+}
+
+// DataScrubber will set Scrubber to replace sensitive data in events sent to Sentry.
+// Use NewScrubber to create one with sensible defaults. Hubs created by RequestLogger and ForkedLogger
+// process all their events (including transactions) with the scrubber.
+func DataScrubber(scrubber *Scrubber) SentryCoreOption {
+	return func(w *SentryCore) {
+		w.Scrubber = scrubber
+	}
+}
+
+// EventRateLimit will set EventLimiter to deduplicate and rate limit events sent to Sentry.
+// Suppressed entries are still stored as breadcrumbs.
+func EventRateLimit(limiter *EventLimiter) SentryCoreOption {
+	return func(w *SentryCore) {
+		w.Limiter = limiter
+	}
+}
+
+// FieldsAsContexts will pass zap.Object and zap.Namespace groups to Sentry as contexts named after the group,
+// other fields will be stored in the context with defaultContext name.
+// Without this option fields are passed to Sentry as extra.
+func FieldsAsContexts(defaultContext string) SentryCoreOption {
+	return func(w *SentryCore) {
+		w.DefaultContext = defaultContext
+	}
+}
+
+// AsyncDelivery will set EventQueue to send events in background instead of blocking the logging goroutine.
+// Hub.LastEventID is not updated for events sent asynchronously, use SentryCore.LastEventID instead.
+func AsyncDelivery(queue *EventQueue) SentryCoreOption {
+	return func(w *SentryCore) {
+		w.Queue = queue
+	}
+}
+
+// FlushTimeout will set maximum duration of Sync, which is also called after entries above Error level.
+func FlushTimeout(timeout time.Duration) SentryCoreOption {
+	return func(w *SentryCore) {
+		w.FlushTimeout = timeout
+	}
+}
+
 func NewSentryCore(hub *sentry.Hub, options ...SentryCoreOption) zapcore.Core {
 	if hub == nil {
 		panic("hub should not be nil")
 	}
 
+	// global processors are not guarded by sentry-go, so the processor is added when the first core is created
+	registerPendingEventsProcessor.Do(func() {
+		sentry.AddGlobalEventProcessor(processPendingEvent)
+	})
+
 	core := &SentryCore{
 		LevelEnabler:    defaultBreadcrumbLevel,
 		hub:             hub,
-		scope:           hub.PushScope(),
+		lastEventID:     &atomic.Value{},
 		BreadcrumbLevel: defaultBreadcrumbLevel,
 		EventLevel:      defaultEventLevel,
+		LogLevel:        defaultLogLevel,
+		FlushTimeout:    defaultFlushTimeout,
 	}
 
 	for _, option := range options {
 		option(core)
 	}
 
+	if core.Logs != nil && core.LogLevel < core.BreadcrumbLevel {
+		core.LevelEnabler = core.LogLevel
+	}
+
 	return core
 }
 
 func (s *SentryCore) With(fields []zapcore.Field) zapcore.Core {
 	clone := &SentryCore{
-		LevelEnabler:    s.LevelEnabler,
-		hub:             s.hub,
-		scope:           s.hub.PushScope(),
-		BreadcrumbLevel: s.BreadcrumbLevel,
-		EventLevel:      s.EventLevel,
-		UserTags:        s.UserTags,
-		GenericTags:     s.GenericTags,
+		LevelEnabler:     s.LevelEnabler,
+		hub:              s.hub,
+		lastEventID:      s.lastEventID,
+		onEvent:          s.onEvent,
+		BreadcrumbLevel:  s.BreadcrumbLevel,
+		EventLevel:       s.EventLevel,
+		LogLevel:         s.LogLevel,
+		Logs:             s.Logs,
+		UserTags:         s.UserTags,
+		GenericTags:      s.GenericTags,
+		TagAliases:       s.TagAliases,
+		FingerprintField: s.FingerprintField,
+		Fingerprinter:    s.Fingerprinter,
+		Scrubber:         s.Scrubber,
+		Limiter:          s.Limiter,
+		Queue:            s.Queue,
+		FlushTimeout:     s.FlushTimeout,
+		DefaultContext:   s.DefaultContext,
+		fields:           s.fields,
 	}
 
 	data := zapcore.NewMapObjectEncoder()
 	for _, field := range fields {
 		field.AddTo(data)
 	}
-	clone.scope.SetExtras(data.Fields)
+	clone.fields = mergeFields(s.fields, data.Fields)
 
 	return clone
 }
 
 func (s *SentryCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
-	if ent.Level >= s.BreadcrumbLevel {
+	if s.accepts(ent.Level) {
 		ce = ce.AddCore(ent, s)
 	}
 	return ce
 }
 
+// accepts reports whether entry with the level should be written as a breadcrumb, an event or a log.
+func (s *SentryCore) accepts(level zapcore.Level) bool {
+	return level >= s.BreadcrumbLevel || (s.Logs != nil && level >= s.LogLevel)
+}
+
 func (s *SentryCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
 	data := zapcore.NewMapObjectEncoder()
 	var errField error
@@ -129,8 +263,18 @@ func (s *SentryCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
 		}
 	}
 
+	if s.Logs != nil && ent.Level >= s.LogLevel {
+		s.sendLog(ent, data, errField)
+	}
+
+	if ent.Level < s.BreadcrumbLevel {
+		return nil
+	}
+
 	if ent.Level >= s.EventLevel {
-		s.captureEvent(ent, data, errField)
+		if allowed, suppressed := s.allowEvent(ent, errField); allowed {
+			s.captureEvent(ent, data, errField, suppressed)
+		}
 	}
 
 	breadcrumb := sentry.Breadcrumb{
@@ -142,18 +286,45 @@ func (s *SentryCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
 	}
 	s.hub.AddBreadcrumb(&breadcrumb, nil)
 
-	if ent.Level > zapcore.ErrorLevel {
+	// DPanic is used for recovered panics, so only entries stopping the process wait for delivery
+	if ent.Level > zapcore.DPanicLevel {
 		_ = s.Sync()
 	}
 
 	return nil
 }
 
-func (s *SentryCore) captureEvent(ent zapcore.Entry, data *zapcore.MapObjectEncoder, errField error) {
+func (s *SentryCore) sendLog(ent zapcore.Entry, data *zapcore.MapObjectEncoder, errField error) {
+	fields := mergeFields(s.fields, data.Fields)
+	if s.Scrubber != nil {
+		ent.Message = s.Scrubber.ScrubString(ent.Message)
+		fields = s.Scrubber.ScrubMap(fields)
+	}
+	s.Logs.add(newLogRecord(ent, fields, errField, s.hub.GetTraceparent()))
+}
+
+func (s *SentryCore) allowEvent(ent zapcore.Entry, errField error) (bool, int) {
+	if s.Limiter == nil {
+		return true, 0
+	}
+	return s.Limiter.allow(eventFingerprint(ent, errField))
+}
+
+func (s *SentryCore) captureEvent(ent zapcore.Entry, data *zapcore.MapObjectEncoder, errField error, suppressed int) {
 	event := sentry.NewEvent()
 	event.Level = SentryLevel(ent.Level)
 	event.Message = ent.Message
-	s.parseFieldsToEvent(event, data.Fields)
+	// fields are merged into a new map, so popping them doesn't affect breadcrumb data
+	user := s.parseFieldsToEvent(event, mergeFields(s.fields, data.Fields))
+	event.Fingerprint = s.prepareFingerprint(ent, &event.Extra, errField)
+	if s.DefaultContext != "" {
+		event.Contexts = s.prepareSentryContexts(event.Extra)
+		event.Extra = make(map[string]interface{})
+	}
+
+	if suppressed != 0 {
+		event.Extra[suppressedEventsExtra] = suppressed
+	}
 
 	if errField != nil {
 		event.Exception = s.convertErrorToException(errField)
@@ -180,7 +351,62 @@ func (s *SentryCore) captureEvent(ent zapcore.Entry, data *zapcore.MapObjectEnco
 		event.Exception[i], event.Exception[opp] = event.Exception[opp], event.Exception[i]
 	}
 
-	s.hub.CaptureEvent(event)
+	s.deliverEvent(event, user)
+}
+
+func (s *SentryCore) deliverEvent(event *sentry.Event, user sentry.User) {
+	client := s.hub.Client()
+	if client == nil {
+		return
+	}
+
+	// hub scope is not modified, so events of other cores using the same hub are not affected
+	scope := &eventScope{
+		scope:    s.hub.Scope(),
+		user:     user,
+		scrubber: s.Scrubber,
+	}
+
+	if s.Queue == nil {
+		if eventID := captureWithHub(s.hub, event, scope); eventID != nil {
+			s.storeEventID(*eventID)
+		}
+		return
+	}
+
+	// event ID is generated in advance to be known before the event is sent
+	event.EventID = newEventID()
+	// scope is copied to keep breadcrumbs as they were at the moment of logging
+	scope.scope = scope.scope.Clone()
+	if s.Queue.enqueue(queuedEvent{
+		client: client,
+		scope:  scope,
+		event:  event,
+	}) {
+		s.storeEventID(event.EventID)
+	}
+}
+
+func (s *SentryCore) storeEventID(eventID sentry.EventID) {
+	s.lastEventID.Store(eventID)
+	if s.onEvent != nil {
+		s.onEvent(eventID)
+	}
+}
+
+// LastEventID returns ID of the last event sent by this core or cores created from it with With.
+// Unlike Hub.LastEventID, it is also updated by events sent with AsyncDelivery.
+func (s *SentryCore) LastEventID() sentry.EventID {
+	eventID, _ := s.lastEventID.Load().(sentry.EventID)
+	return eventID
+}
+
+// DeliveryStats returns counters of the EventQueue, they are always zero if events are sent synchronously.
+func (s *SentryCore) DeliveryStats() EventQueueStats {
+	if s.Queue == nil {
+		return EventQueueStats{}
+	}
+	return s.Queue.Stats()
 }
 
 func (s *SentryCore) convertErrorToException(errValue error) []sentry.Exception {
@@ -219,42 +445,144 @@ func (s *SentryCore) convertErrorToException(errValue error) []sentry.Exception
 }
 
 func (s *SentryCore) Sync() error {
-	s.hub.Flush(30 * time.Second)
+	deadline := time.Now().Add(s.FlushTimeout)
+	if s.Logs != nil && !s.Logs.flush(s.FlushTimeout) {
+		return nil
+	}
+	if s.Queue != nil && !s.Queue.flush(time.Until(deadline)) {
+		return nil
+	}
+	s.hub.Flush(time.Until(deadline))
 	return nil
 }
 
-func (s *SentryCore) parseFieldsToEvent(event *sentry.Event, data map[string]interface{}) {
-	event.User = s.prepareSentryUser(&data)
+// parseFieldsToEvent maps fields to event tags and extra, returned user should be merged with the scope user.
+func (s *SentryCore) parseFieldsToEvent(event *sentry.Event, data map[string]interface{}) sentry.User {
+	user := s.prepareSentryUser(&data)
 	event.Tags = s.prepareSentryTags(&data)
 	event.Extra = data
+	return user
 }
 
 func (s *SentryCore) prepareSentryUser(data *map[string]interface{}) sentry.User {
-	return sentry.User{
-		ID:        fmt.Sprintf("%v", pop(data, s.UserTags.ID)),
-		IPAddress: fmt.Sprintf("%v", pop(data, s.UserTags.IPAddress)),
-		Name:      fmt.Sprintf("%v", pop(data, s.UserTags.Name)),
-		Username:  fmt.Sprintf("%v", pop(data, s.UserTags.Username)),
-		Email:     fmt.Sprintf("%v", pop(data, s.UserTags.Email)),
-		Segment:   fmt.Sprintf("%v", pop(data, s.UserTags.Segment)),
+	user := sentry.User{
+		ID:        fieldString(pop(data, s.UserTags.ID)),
+		IPAddress: fieldString(pop(data, s.UserTags.IPAddress)),
+		Name:      fieldString(pop(data, s.UserTags.Name)),
+		Username:  fieldString(pop(data, s.UserTags.Username)),
+		Email:     fieldString(pop(data, s.UserTags.Email)),
+		Segment:   fieldString(pop(data, s.UserTags.Segment)),
+	}
+	for key, fieldPath := range s.UserTags.Data {
+		if val := fieldString(pop(data, fieldPath)); val != "" {
+			if user.Data == nil {
+				user.Data = make(map[string]string, len(s.UserTags.Data))
+			}
+			user.Data[key] = val
+		}
 	}
+	return user
+}
+
+func (s *SentryCore) prepareFingerprint(
+	ent zapcore.Entry, data *map[string]interface{}, errField error,
+) []string {
+	if s.FingerprintField != "" {
+		if fingerprint := fingerprintFromField(pop(data, s.FingerprintField)); len(fingerprint) != 0 {
+			return fingerprint
+		}
+	}
+	if s.Fingerprinter != nil {
+		return s.Fingerprinter(ent, *data, errField)
+	}
+	return nil
 }
 
 func (s *SentryCore) prepareSentryTags(data *map[string]interface{}) map[string]string {
 	tags := make(map[string]string, 0)
 	for _, tagKey := range s.GenericTags {
-		val := fmt.Sprintf("%v", pop(data, tagKey))
-		if val != "" {
-			tags[tagKey] = val
+		if !isFieldPattern(tagKey) {
+			s.addTag(tags, tagKey, pop(data, tagKey))
+			continue
 		}
+		for _, fieldPath := range matchPaths(*data, tagKey) {
+			s.addTag(tags, fieldPath, pop(data, fieldPath))
+		}
+	}
+	for fieldPath := range s.TagAliases {
+		s.addTag(tags, fieldPath, pop(data, fieldPath))
 	}
 	return tags
 }
 
+func (s *SentryCore) addTag(tags map[string]string, fieldPath string, value interface{}) {
+	val := fieldString(value)
+	if val == "" {
+		return
+	}
+	if alias, ok := s.TagAliases[fieldPath]; ok {
+		fieldPath = alias
+	}
+	tags[fieldPath] = val
+}
+
+func (s *SentryCore) prepareSentryContexts(data map[string]interface{}) map[string]sentry.Context {
+	contexts := make(map[string]sentry.Context)
+	loose := make(sentry.Context)
+	for key, value := range data {
+		if group, ok := value.(map[string]interface{}); ok && key != traceContextKey {
+			contexts[key] = group
+		} else {
+			loose[key] = value
+		}
+	}
+
+	if len(loose) != 0 {
+		// group with the same name as default context is merged with loose fields
+		contexts[s.DefaultContext] = mergeFields(contexts[s.DefaultContext], loose)
+	}
+	return contexts
+}
+
+// mergeFields returns a new map with fields from both maps, fields from override map take precedence.
+// Groups present in both maps are merged recursively.
+func mergeFields(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		baseGroup, baseIsGroup := merged[key].(map[string]interface{})
+		group, isGroup := value.(map[string]interface{})
+		if baseIsGroup && isGroup {
+			value = mergeFields(baseGroup, group)
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// fieldString formats field value, nil values are formatted as empty string.
+func fieldString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	switch rv := reflect.ValueOf(value); rv.Kind() { //nolint:exhaustive
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if rv.IsNil() {
+			return ""
+		}
+	}
+	if val := fmt.Sprintf("%v", value); val != "<nil>" {
+		// zap encodes nil fmt.Stringer as "<nil>"
+		return val
+	}
+	return ""
+}
+
 func pop(fieldMap *map[string]interface{}, key string) interface{} {
-	val, ok := (*fieldMap)[key]
+	val, ok := popPath(*fieldMap, key)
 	if ok {
-		delete(*fieldMap, key)
 		return val
 	}
 	return ""
@@ -3,7 +3,9 @@ package logger
 import (
 	stderrors "errors"
 	"fmt"
+	"net"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -97,6 +99,53 @@ func (suite *SentryCoreSuite) TestNew() {
 
 			suite.Equal([]string{"t1", "t2"}, hub.GenericTags)
 		})
+
+		suite.Run("tag aliases", func() {
+			aliases := map[string]string{"user.org.id": "org_id"}
+			hub := NewSentryCore(suite.hub, TagAliases(aliases)).(*SentryCore)
+
+			suite.Equal(aliases, hub.TagAliases)
+		})
+
+		suite.Run("fingerprint field", func() {
+			hub := NewSentryCore(suite.hub, FingerprintField("fingerprint")).(*SentryCore)
+
+			suite.Equal("fingerprint", hub.FingerprintField)
+		})
+
+		suite.Run("fingerprinter", func() {
+			hub := NewSentryCore(suite.hub, Fingerprinter(FingerprintByMessage)).(*SentryCore)
+
+			suite.NotNil(hub.Fingerprinter)
+		})
+
+		suite.Run("fields as contexts", func() {
+			hub := NewSentryCore(suite.hub, FieldsAsContexts("fields")).(*SentryCore)
+
+			suite.Equal("fields", hub.DefaultContext)
+		})
+
+		suite.Run("async delivery", func() {
+			queue := NewEventQueue(10, DropOldest)
+			hub := NewSentryCore(suite.hub, AsyncDelivery(queue), FlushTimeout(time.Second)).(*SentryCore)
+
+			suite.Equal(queue, hub.Queue)
+			suite.Equal(time.Second, hub.FlushTimeout)
+		})
+
+		suite.Run("event rate limit", func() {
+			limiter := NewEventLimiter(time.Minute, EventLimit{}, EventLimit{})
+			hub := NewSentryCore(suite.hub, EventRateLimit(limiter)).(*SentryCore)
+
+			suite.Equal(limiter, hub.Limiter)
+		})
+
+		suite.Run("data scrubber", func() {
+			scrubber := NewScrubber()
+			hub := NewSentryCore(suite.hub, DataScrubber(scrubber)).(*SentryCore)
+
+			suite.Equal(scrubber, hub.Scrubber)
+		})
 	})
 }
 
@@ -173,6 +222,14 @@ func (suite *SentryCoreSuite) TestWriteOnFatalLevelsTriggerSync() {
 	})
 }
 
+func (suite *SentryCoreSuite) TestWriteOnDPanicLevelDoesNotTriggerSync() {
+	suite.sendEventMock()
+	suite.flushMock.Times(0)
+
+	logger := zap.New(NewSentryCore(suite.hub))
+	logger.DPanic("recovered panic msg")
+}
+
 func (suite *SentryCoreSuite) TestWriteWillAttachStacktrace() {
 	core := NewSentryCore(suite.hub)
 	logger := zap.New(core)
@@ -301,6 +358,370 @@ func (suite *SentryCoreSuite) TestParsingSentryTags() {
 	)
 }
 
+func (suite *SentryCoreSuite) TestParsingNestedSentryTags() {
+	core := NewSentryCore(
+		suite.hub,
+		UserTags(SentryUserTagMap{ID: "user.id", Email: "user.email"}),
+		GenericTags("http.*", "component"),
+		TagAliases(map[string]string{"user.org.id": "org_id", "component": "app.component"}),
+	)
+	logger := zap.New(core)
+
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal("42", event.User.ID)
+		suite.Equal("user@example.com", event.User.Email)
+		suite.Equal(map[string]string{
+			"http.method":   "GET",
+			"http.status":   "200",
+			"org_id":        "7",
+			"app.component": "api",
+		}, event.Tags)
+		suite.Equal(map[string]interface{}{
+			"user": map[string]interface{}{"name": "test"},
+			"http": map[string]interface{}{
+				"request": map[string]interface{}{"id": "abc"},
+			},
+		}, event.Extra)
+	})
+
+	logger.Error(
+		"message with nested fields",
+		zap.String("component", "api"),
+		zap.Dict("user",
+			zap.String("id", "42"),
+			zap.String("name", "test"),
+			zap.String("email", "user@example.com"),
+			zap.Dict("org", zap.String("id", "7")),
+		),
+		zap.Namespace("http"),
+		zap.String("method", "GET"),
+		zap.Int("status", 200),
+		zap.Dict("request", zap.String("id", "abc")),
+	)
+}
+
+func (suite *SentryCoreSuite) TestUserMergedWithScopeUser() {
+	suite.hub.Scope().SetUser(sentry.User{
+		ID:        "anonymous",
+		IPAddress: "192.0.2.1",
+		Data:      map[string]string{"plan": "free"},
+	})
+	userTags := SentryUserTagMap{
+		ID:       "user_id",
+		Email:    "email",
+		Username: "username",
+		Data:     map[string]string{"plan": "plan", "org": "org.id"},
+	}
+	logger := zap.New(NewSentryCore(suite.hub, UserTags(userTags)))
+
+	suite.Run("logged fields override scope user", func() {
+		suite.sendEventMock().Do(func(event *sentry.Event) {
+			suite.Equal(sentry.User{
+				ID:        "42",
+				IPAddress: "192.0.2.1",
+				Data:      map[string]string{"plan": "pro", "org": "7"},
+			}, event.User)
+		})
+		logger.Error(
+			"test",
+			zap.Int("user_id", 42),
+			zap.Any("email", nil),
+			zap.Stringer("username", (*net.IPAddr)(nil)),
+			zap.String("plan", "pro"),
+			zap.Dict("org", zap.Int("id", 7)),
+		)
+	})
+
+	suite.Run("scope user is kept without fields", func() {
+		suite.sendEventMock().Do(func(event *sentry.Event) {
+			suite.Equal(sentry.User{
+				ID:        "anonymous",
+				IPAddress: "192.0.2.1",
+				Data:      map[string]string{"plan": "free"},
+			}, event.User)
+		})
+		logger.Error("test")
+	})
+}
+
+func (suite *SentryCoreSuite) TestBoundFieldsMapping() {
+	core := NewSentryCore(
+		suite.hub,
+		UserTags(SentryUserTagMap{ID: "user.id", Email: "user.email"}),
+		GenericTags("tenant", "component"),
+		FingerprintField("fingerprint"),
+	)
+	logger := zap.New(core).With(
+		zap.Dict("user", zap.String("id", "42")),
+		zap.String("tenant", "acme"),
+		zap.String("component", "bound"),
+		zap.String("fingerprint", "payments"),
+		zap.String("bound", "value"),
+	)
+
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal("42", event.User.ID)
+		suite.Equal("user@example.com", event.User.Email)
+		suite.Equal(map[string]string{"tenant": "acme", "component": "call"}, event.Tags)
+		suite.Equal([]string{"payments"}, event.Fingerprint)
+		suite.Equal(map[string]interface{}{"bound": "value", "call": "value"}, event.Extra)
+
+		suite.Require().Len(event.Breadcrumbs, 1)
+		suite.Equal(map[string]interface{}{"call": "value"}, event.Breadcrumbs[0].Data)
+	})
+
+	logger.Info("breadcrumb", zap.String("call", "value"))
+	logger.Error(
+		"test",
+		zap.Dict("user", zap.String("email", "user@example.com")),
+		zap.String("component", "call"),
+		zap.String("call", "value"),
+	)
+}
+
+func (suite *SentryCoreSuite) TestBoundFieldsAsContexts() {
+	logger := zap.New(NewSentryCore(suite.hub, FieldsAsContexts("fields"))).With(
+		zap.Dict("order", zap.Int("id", 1), zap.String("currency", "EUR")),
+		zap.String("component", "payments"),
+	)
+
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal(sentry.Context{"id": int64(2), "currency": "EUR"}, event.Contexts["order"])
+		suite.Equal(sentry.Context{"component": "payments", "attempt": int64(1)}, event.Contexts["fields"])
+	})
+
+	logger.Error("test", zap.Dict("order", zap.Int("id", 2)), zap.Int("attempt", 1))
+}
+
+func (suite *SentryCoreSuite) TestWithDoesNotModifyHubScope() {
+	scope := suite.hub.Scope()
+	logger := zap.New(NewSentryCore(suite.hub))
+
+	first := logger.With(zap.String("first", "value"))
+	second := logger.With(zap.String("second", "value"))
+	suite.Same(scope, suite.hub.Scope(), "hub scope stack should not grow")
+
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal(map[string]interface{}{"first": "value"}, event.Extra)
+	})
+	first.Error("test")
+
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Empty(event.Extra, "fields of loggers should not leak into hub events")
+	})
+	suite.hub.CaptureMessage("test")
+
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal("value", event.Extra["second"])
+		suite.NotContains(event.Extra, "first")
+		suite.Contains(event.Extra, "goroutine")
+	}).Times(10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			second.With(zap.Int("goroutine", i)).Error("concurrent")
+		}(i)
+	}
+	wg.Wait()
+}
+
+func (suite *SentryCoreSuite) TestLastEventID() {
+	core := NewSentryCore(suite.hub).(*SentryCore)
+	logger := zap.New(core).With(zap.String("key", "value"))
+	suite.Empty(core.LastEventID())
+
+	var eventID sentry.EventID
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		eventID = event.EventID
+	})
+	logger.Error("test")
+	suite.NotEmpty(eventID)
+	suite.Equal(eventID, core.LastEventID(), "clones should share last event id")
+	suite.Equal(eventID, suite.hub.LastEventID(), "events sent synchronously should update hub last event id")
+
+	suite.Run("async", func() {
+		core := NewSentryCore(suite.hub, AsyncDelivery(NewEventQueue(1, DropNewest))).(*SentryCore)
+
+		suite.sendEventMock().Do(func(event *sentry.Event) {
+			eventID = event.EventID
+		})
+		zap.New(core).Error("test")
+		asyncEventID := core.LastEventID()
+		suite.Require().NoError(core.Sync())
+		suite.Equal(eventID, asyncEventID, "event id should be known before the event is sent")
+		suite.NotEqual(eventID, suite.hub.LastEventID())
+	})
+}
+
+func (suite *SentryCoreSuite) TestFingerprint() {
+	core := NewSentryCore(suite.hub, FingerprintField("fingerprint"), Fingerprinter(FingerprintByMessageAndRootError))
+	logger := zap.New(core)
+
+	suite.Run("field", func() {
+		suite.sendEventMock().Do(func(event *sentry.Event) {
+			suite.Equal([]string{FingerprintDefault, "payments"}, event.Fingerprint)
+			suite.Equal(map[string]interface{}{"id": int64(1)}, event.Extra, "fingerprint field should not be passed as extra")
+		})
+		logger.Error("payment 1 failed", zap.Strings("fingerprint", []string{FingerprintDefault, "payments"}), zap.Int("id", 1))
+	})
+
+	suite.Run("string field", func() {
+		suite.sendEventMock().Do(func(event *sentry.Event) {
+			suite.Equal([]string{"payments"}, event.Fingerprint)
+		})
+		logger.Error("payment 2 failed", zap.String("fingerprint", "payments"))
+	})
+
+	suite.Run("fingerprinter", func() {
+		suite.sendEventMock().Do(func(event *sentry.Event) {
+			suite.Equal([]string{"request failed", "*errors.fundamental"}, event.Fingerprint)
+		})
+		logger.Error("request failed", zap.Error(fmt.Errorf("wrapped: %w", errors.New("timeout"))))
+	})
+
+	suite.Run("default grouping", func() {
+		suite.sendEventMock().Do(func(event *sentry.Event) {
+			suite.Empty(event.Fingerprint)
+		})
+		zap.New(NewSentryCore(suite.hub)).Error("request failed", zap.String("fingerprint", "ignored"))
+	})
+}
+
+func (suite *SentryCoreSuite) TestAsyncDelivery() {
+	core := NewSentryCore(suite.hub, AsyncDelivery(NewEventQueue(10, DropNewest))).(*SentryCore)
+	logger := zap.New(core).With(zap.String("component", "test"))
+
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal("async error", event.Message)
+		suite.Equal(map[string]interface{}{"component": "test", "i": int64(1)}, event.Extra)
+		suite.Require().Len(event.Breadcrumbs, 1, "breadcrumbs added after the event should not be sent")
+		suite.Equal("before error", event.Breadcrumbs[0].Message)
+	})
+
+	logger.Info("before error")
+	logger.Error("async error", zap.Int("i", 1))
+	logger.Info("after error")
+
+	suite.Require().NoError(logger.Sync())
+	suite.Equal(EventQueueStats{Enqueued: 1, Sent: 1}, core.DeliveryStats())
+	suite.Equal(EventQueueStats{}, NewSentryCore(suite.hub).(*SentryCore).DeliveryStats())
+}
+
+func (suite *SentryCoreSuite) TestAsyncDeliveryDroppedEventID() {
+	queue := NewEventQueue(1, DropNewest)
+	queue.Close()
+	core := NewSentryCore(suite.hub, AsyncDelivery(queue)).(*SentryCore)
+
+	zap.New(core).Error("dropped error")
+
+	suite.Empty(core.LastEventID(), "ID of the dropped event should not be published")
+	suite.Equal(EventQueueStats{Dropped: 1}, core.DeliveryStats())
+}
+
+func (suite *SentryCoreSuite) TestFieldsAsContexts() {
+	core := NewSentryCore(suite.hub, FieldsAsContexts("fields"), GenericTags("tag"))
+	logger := zap.New(core).With(zap.String("component", "payments"), zap.Int("attempt", 1))
+
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Empty(event.Extra)
+		suite.Equal(map[string]string{"tag": "value"}, event.Tags)
+
+		suite.Equal(sentry.Context{
+			"component": "payments",
+			"attempt":   int64(2),
+			"trace":     "not a group",
+		}, event.Contexts["fields"])
+		suite.Equal(sentry.Context{"id": int64(42), "currency": "EUR"}, event.Contexts["order"])
+		suite.Equal(sentry.Context{"name": "test"}, event.Contexts["customer"])
+		suite.Contains(event.Contexts, "trace", "trace context should be set by sentry")
+	})
+
+	logger.Error(
+		"payment failed",
+		zap.Int("attempt", 2),
+		zap.String("tag", "value"),
+		zap.String("trace", "not a group"),
+		zap.Object("customer", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("name", "test")
+			return nil
+		})),
+		zap.Namespace("order"),
+		zap.Int("id", 42),
+		zap.String("currency", "EUR"),
+	)
+}
+
+func (suite *SentryCoreSuite) TestFieldsAsContextsMergeDefaultContext() {
+	logger := zap.New(NewSentryCore(suite.hub, FieldsAsContexts("fields")))
+
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal(sentry.Context{"grouped": true, "loose": true}, event.Contexts["fields"])
+	})
+
+	logger.Error(
+		"test",
+		zap.Bool("loose", true),
+		zap.Dict("fields", zap.Bool("grouped", true)),
+	)
+}
+
+func (suite *SentryCoreSuite) TestScrubbing() {
+	core := NewSentryCore(suite.hub, DataScrubber(NewScrubber()))
+	logger := zap.New(core).With(zap.String("api_key", "secret"))
+
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal("login failed for [Filtered]", event.Message)
+		suite.Equal(map[string]interface{}{
+			"api_key": "[Filtered]",
+			"request": map[string]interface{}{
+				"password": "[Filtered]",
+				"url":      "https://example.com/login?token=[Filtered]",
+			},
+		}, event.Extra)
+
+		suite.Require().Len(event.Breadcrumbs, 1)
+		suite.Equal(map[string]interface{}{"token": "[Filtered]"}, event.Breadcrumbs[0].Data)
+	})
+
+	logger.Debug("breadcrumb", zap.String("token", "secret"))
+	logger.Error("login failed for john@example.com", zap.Any("request", map[string]string{
+		"password": "hunter2",
+		"url":      "https://example.com/login?token=secret",
+	}))
+}
+
+func (suite *SentryCoreSuite) TestEventRateLimit() {
+	now := time.Now()
+	limiter := NewEventLimiter(time.Minute, EventLimit{}, EventLimit{})
+	limiter.now = func() time.Time {
+		return now
+	}
+
+	logger := zap.New(NewSentryCore(suite.hub, EventRateLimit(limiter)))
+
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal("error in loop", event.Message)
+		suite.Equal(map[string]interface{}{"i": int64(0)}, event.Extra)
+	})
+	for i := 0; i < 3; i++ {
+		logger.Error("error in loop", zap.Int("i", i))
+	}
+
+	now = now.Add(time.Minute)
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal(map[string]interface{}{"i": int64(3), suppressedEventsExtra: 2}, event.Extra)
+
+		suite.Require().Len(event.Breadcrumbs, 3, "suppressed events should be stored as breadcrumbs")
+		for i, breadcrumb := range event.Breadcrumbs {
+			suite.Equal(map[string]interface{}{"i": int64(i)}, breadcrumb.Data)
+		}
+	})
+	logger.Error("error in loop", zap.Int("i", 3))
+}
+
 func TestSentryCore(t *testing.T) {
 	suite.Run(t, new(SentryCoreSuite))
 }
@@ -3,6 +3,7 @@ package logger
 import (
 	"github.com/getsentry/sentry-go"
 	"go.uber.org/multierr"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -22,6 +23,17 @@ func (w sentryCoreWrapper) LocalCore() zapcore.Core {
 	return w[0]
 }
 
+// localOnly is a logger option dropping the Sentry core of the wrapper, so entries are not duplicated
+// as breadcrumbs when a breadcrumb is added explicitly.
+func localOnly() zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		if wrappedCore, ok := core.(sentryCoreWrapper); ok {
+			return wrappedCore.LocalCore()
+		}
+		return core
+	})
+}
+
 func (w sentryCoreWrapper) SentryCore() *SentryCore {
 	return w[1].(*SentryCore) //nolint: forcetypeassert
 }
@@ -0,0 +1,350 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultLogLevel = zapcore.InfoLevel
+
+	logItemContentType = "application/vnd.sentry.items.log+json"
+
+	defaultLogSendTimeout       = 30 * time.Second
+	defaultLogBatchesInFlight   = 4
+	defaultLogRateLimitDuration = time.Minute
+)
+
+var errNoDsn = errors.New("sentry client has no DSN")
+
+// logAttribute is a typed value of Sentry log attribute.
+type logAttribute struct {
+	Value interface{} `json:"value"`
+	Type  string      `json:"type"`
+}
+
+// logRecord is a log entry in Sentry Logs format.
+type logRecord struct {
+	Timestamp      float64                 `json:"timestamp"`
+	TraceID        string                  `json:"trace_id,omitempty"`
+	Level          string                  `json:"level"`
+	SeverityNumber int                     `json:"severity_number"`
+	Body           string                  `json:"body"`
+	Attributes     map[string]logAttribute `json:"attributes,omitempty"`
+}
+
+// LogBatcherStats contains counters of log records passed through LogBatcher.
+type LogBatcherStats struct {
+	Sent    uint64
+	Dropped uint64
+}
+
+// LogBatcher sends log records to Sentry Logs in batches.
+// Batch is sent when it has batchSize records or flushInterval passed since the first record was added.
+// One batcher could be shared between several cores.
+//
+// Every send is limited by 30 seconds timeout and up to 4 batches are sent concurrently, batches exceeding
+// the limit are dropped. After 429 response batches are dropped until Retry-After passes (1 minute by default).
+// Envelopes are posted with HTTPClient or HTTPTransport of the client options, configured sentry.Transport
+// isn't used because it can only send events.
+type LogBatcher struct {
+	dsn        *sentry.Dsn
+	httpClient *http.Client
+	attributes map[string]logAttribute
+
+	batchSize     int
+	flushInterval time.Duration
+	sendTimeout   time.Duration
+	inFlight      chan struct{}
+
+	mu           sync.Mutex
+	records      []logRecord
+	timer        *time.Timer
+	sending      sync.WaitGroup
+	limitedUntil time.Time
+
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// NewLogBatcher creates LogBatcher sending logs to the project of the client DSN.
+// Environment, release and server name of the client are added to every record.
+func NewLogBatcher(client *sentry.Client, batchSize int, flushInterval time.Duration) (*LogBatcher, error) {
+	options := client.Options()
+	if options.Dsn == "" {
+		return nil, errNoDsn
+	}
+	dsn, err := sentry.NewDsn(options.Dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSN: %w", err)
+	}
+
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Transport: options.HTTPTransport}
+	}
+
+	attributes := map[string]logAttribute{
+		"sentry.sdk.name":    {Value: client.GetSDKIdentifier(), Type: "string"},
+		"sentry.sdk.version": {Value: sentry.SDKVersion, Type: "string"},
+	}
+	for key, value := range map[string]string{
+		"sentry.environment": options.Environment,
+		"sentry.release":     options.Release,
+		"server.address":     options.ServerName,
+	} {
+		if value != "" {
+			attributes[key] = logAttribute{Value: value, Type: "string"}
+		}
+	}
+
+	return &LogBatcher{
+		dsn:           dsn,
+		httpClient:    httpClient,
+		attributes:    attributes,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		sendTimeout:   defaultLogSendTimeout,
+		inFlight:      make(chan struct{}, defaultLogBatchesInFlight),
+	}, nil
+}
+
+// Stats returns current values of batcher counters.
+func (b *LogBatcher) Stats() LogBatcherStats {
+	return LogBatcherStats{
+		Sent:    b.sent.Load(),
+		Dropped: b.dropped.Load(),
+	}
+}
+
+func (b *LogBatcher) add(record logRecord) {
+	for key, attribute := range b.attributes {
+		if _, ok := record.Attributes[key]; !ok {
+			record.Attributes[key] = attribute
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.records = append(b.records, record)
+	if len(b.records) >= b.batchSize {
+		b.sendLocked()
+		return
+	}
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.flushInterval, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.sendLocked()
+		})
+	}
+}
+
+// sendLocked sends pending records in background, b.mu should be held.
+func (b *LogBatcher) sendLocked() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.records) == 0 {
+		return
+	}
+
+	records := b.records
+	b.records = nil
+
+	if time.Now().Before(b.limitedUntil) {
+		b.dropped.Add(uint64(len(records)))
+		return
+	}
+	select {
+	case b.inFlight <- struct{}{}:
+	default:
+		b.dropped.Add(uint64(len(records)))
+		sentry.Logger.Printf("Dropping %d logs: too many batches are being sent", len(records))
+		return
+	}
+
+	b.sending.Add(1)
+	go func() {
+		defer b.sending.Done()
+		defer func() { <-b.inFlight }()
+
+		if err := b.send(records); err != nil {
+			b.dropped.Add(uint64(len(records)))
+			sentry.Logger.Printf("Sending logs failed: %v", err)
+			return
+		}
+		b.sent.Add(uint64(len(records)))
+	}()
+}
+
+// limit drops all batches until Retry-After of the response passes.
+func (b *LogBatcher) limit(resp *http.Response) {
+	duration := defaultLogRateLimitDuration
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			duration = time.Duration(seconds) * time.Second
+		} else if date, err := http.ParseTime(value); err == nil {
+			duration = time.Until(date)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.limitedUntil = time.Now().Add(duration)
+}
+
+// flush sends pending records and waits until all batches are sent, reports whether it happened before the timeout.
+func (b *LogBatcher) flush(timeout time.Duration) bool {
+	b.mu.Lock()
+	b.sendLocked()
+	b.mu.Unlock()
+
+	sent := make(chan struct{})
+	go func() {
+		b.sending.Wait()
+		close(sent)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-sent:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (b *LogBatcher) send(records []logRecord) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+
+	header := map[string]interface{}{
+		"sent_at": time.Now().UTC().Format(time.RFC3339Nano),
+		"dsn":     b.dsn.String(),
+	}
+	itemHeader := map[string]interface{}{
+		"type":         "log",
+		"item_count":   len(records),
+		"content_type": logItemContentType,
+	}
+	for _, part := range []interface{}{header, itemHeader, map[string]interface{}{"items": records}} {
+		if err := enc.Encode(part); err != nil {
+			return fmt.Errorf("encoding envelope: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.sendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.dsn.GetAPIURL().String(), &body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	for key, value := range b.dsn.RequestHeaders() {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending envelope: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		b.limit(resp)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected response status: %s", resp.Status) //nolint:goerr113
+	}
+	return nil
+}
+
+// newLogRecord creates log record from the entry, fields are flattened to attributes with dotted keys.
+func newLogRecord(ent zapcore.Entry, fields map[string]interface{}, errField error, traceparent string) logRecord {
+	level, severity := sentryLogLevel(ent.Level)
+	if ent.Time.IsZero() {
+		ent.Time = time.Now()
+	}
+	record := logRecord{
+		Timestamp:      float64(ent.Time.UnixNano()) / float64(time.Second),
+		Level:          level,
+		SeverityNumber: severity,
+		Body:           ent.Message,
+		Attributes:     make(map[string]logAttribute),
+	}
+
+	// traceparent format is "trace_id-span_id[-sampled]"
+	if parts := strings.Split(traceparent, "-"); len(parts) >= 2 {
+		record.TraceID = parts[0]
+		record.Attributes["sentry.trace.parent_span_id"] = logAttribute{Value: parts[1], Type: "string"}
+	}
+
+	addLogAttributes(record.Attributes, "", fields)
+	if errField != nil {
+		record.Attributes["error"] = logAttribute{Value: errField.Error(), Type: "string"}
+	}
+	if ent.LoggerName != "" {
+		record.Attributes["logger.name"] = logAttribute{Value: ent.LoggerName, Type: "string"}
+	}
+	if ent.Caller.Defined {
+		record.Attributes["code.file.path"] = logAttribute{Value: ent.Caller.File, Type: "string"}
+		record.Attributes["code.line.number"] = logAttribute{Value: int64(ent.Caller.Line), Type: "integer"}
+	}
+	return record
+}
+
+func addLogAttributes(attributes map[string]logAttribute, prefix string, fields map[string]interface{}) {
+	for key, value := range fields {
+		if prefix != "" {
+			key = prefix + fieldPathSeparator + key
+		}
+		if group, ok := value.(map[string]interface{}); ok {
+			addLogAttributes(attributes, key, group)
+			continue
+		}
+		attributes[key] = newLogAttribute(value)
+	}
+}
+
+func newLogAttribute(value interface{}) logAttribute {
+	switch typed := value.(type) {
+	case string:
+		return logAttribute{Value: typed, Type: "string"}
+	case bool:
+		return logAttribute{Value: typed, Type: "boolean"}
+	case int, int8, int16, int32, int64, uint8, uint16, uint32:
+		return logAttribute{Value: typed, Type: "integer"}
+	case float32, float64:
+		return logAttribute{Value: typed, Type: "double"}
+	case time.Duration:
+		return logAttribute{Value: typed.String(), Type: "string"}
+	case time.Time:
+		return logAttribute{Value: typed.Format(time.RFC3339Nano), Type: "string"}
+	case fmt.Stringer:
+		return logAttribute{Value: typed.String(), Type: "string"}
+	}
+
+	// complex values are passed as JSON
+	data, err := json.Marshal(value)
+	if err != nil {
+		return logAttribute{Value: fmt.Sprintf("%v", value), Type: "string"}
+	}
+	return logAttribute{Value: string(data), Type: "string"}
+}
@@ -0,0 +1,215 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type SentryLogsSuite struct {
+	suite.Suite
+
+	ctrl   *gomock.Controller
+	server *httptest.Server
+	client *sentry.Client
+
+	envelopes chan []map[string]interface{}
+	// respond is called by the server after the envelope is received, if set
+	respond func(w http.ResponseWriter)
+}
+
+func (s *SentryLogsSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.envelopes = make(chan []map[string]interface{}, 10)
+	s.respond = nil
+
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Equal("/api/1/envelope/", r.URL.Path)
+		s.Equal("application/x-sentry-envelope", r.Header.Get("Content-Type"))
+		s.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=public")
+
+		var envelope []map[string]interface{}
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var part map[string]interface{}
+			s.NoError(json.Unmarshal(scanner.Bytes(), &part))
+			envelope = append(envelope, part)
+		}
+		s.envelopes <- envelope
+
+		if s.respond != nil {
+			s.respond(w)
+		}
+	}))
+
+	transportMock := NewMockTransport(s.ctrl)
+	transportMock.EXPECT().
+		Configure(gomock.AssignableToTypeOf(sentry.ClientOptions{})).
+		Return()
+	transportMock.EXPECT().
+		SendEvent(gomock.AssignableToTypeOf(&sentry.Event{})).
+		Return().
+		MinTimes(0)
+	transportMock.EXPECT().
+		Flush(gomock.Any()).
+		Return(true).
+		MinTimes(0)
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:         strings.Replace(s.server.URL, "http://", "http://public@", 1) + "/1",
+		Environment: "test",
+		Transport:   transportMock,
+	})
+	s.Require().NoError(err)
+	s.client = client
+}
+
+func (s *SentryLogsSuite) TearDownTest() {
+	s.server.Close()
+	s.ctrl.Finish()
+}
+
+func (s *SentryLogsSuite) items(envelope []map[string]interface{}) []interface{} {
+	s.Require().Len(envelope, 3)
+	s.Contains(envelope[0], "dsn")
+	s.Equal("log", envelope[1]["type"])
+	s.Equal(logItemContentType, envelope[1]["content_type"])
+
+	items, ok := envelope[2]["items"].([]interface{})
+	s.Require().True(ok)
+	s.EqualValues(len(items), envelope[1]["item_count"])
+	return items
+}
+
+func (s *SentryLogsSuite) TestLogs() {
+	batcher, err := NewLogBatcher(s.client, 10, time.Hour)
+	s.Require().NoError(err)
+
+	hub := sentry.NewHub(s.client, sentry.NewScope())
+	core := NewSentryCore(hub, BreadcrumbLevel(zapcore.WarnLevel), SentryLogs(batcher, zapcore.InfoLevel))
+	logger := zap.New(core).With(zap.String("component", "test"))
+
+	s.True(core.Enabled(zapcore.InfoLevel), "core should accept entries for logs below breadcrumb level")
+	s.False(core.Enabled(zapcore.DebugLevel))
+
+	logger.Debug("skipped")
+	logger.Info("first", zap.Dict("user", zap.Int("id", 42)), zap.Duration("took", time.Second))
+	logger.Warn("second", zap.Error(errors.New("failure")))
+	s.Require().NoError(logger.Sync())
+
+	items := s.items(<-s.envelopes)
+	s.Require().Len(items, 2)
+
+	first := items[0].(map[string]interface{})
+	s.Equal("info", first["level"])
+	s.EqualValues(9, first["severity_number"])
+	s.Equal("first", first["body"])
+	s.NotEmpty(first["trace_id"])
+	attributes := first["attributes"].(map[string]interface{})
+	s.Equal(map[string]interface{}{"value": "test", "type": "string"}, attributes["component"])
+	s.Equal(map[string]interface{}{"value": float64(42), "type": "integer"}, attributes["user.id"])
+	s.Equal(map[string]interface{}{"value": "1s", "type": "string"}, attributes["took"])
+	s.Equal(map[string]interface{}{"value": "test", "type": "string"}, attributes["sentry.environment"])
+	s.Contains(attributes, "sentry.trace.parent_span_id")
+
+	second := items[1].(map[string]interface{})
+	s.Equal("warn", second["level"])
+	s.Equal(map[string]interface{}{"value": "failure", "type": "string"},
+		second["attributes"].(map[string]interface{})["error"])
+}
+
+func (s *SentryLogsSuite) TestBatching() {
+	batcher, err := NewLogBatcher(s.client, 2, 10*time.Millisecond)
+	s.Require().NoError(err)
+
+	logger := zap.New(NewSentryCore(sentry.NewHub(s.client, sentry.NewScope()), SentryLogs(batcher, zapcore.InfoLevel)))
+
+	for i := 0; i < 3; i++ {
+		logger.Info("test", zap.Int("i", i))
+	}
+
+	s.Len(s.items(<-s.envelopes), 2, "full batch should be sent immediately")
+	s.Len(s.items(<-s.envelopes), 1, "incomplete batch should be sent after flush interval")
+}
+
+func (s *SentryLogsSuite) TestRateLimit() {
+	s.respond = func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+
+	batcher, err := NewLogBatcher(s.client, 1, time.Hour)
+	s.Require().NoError(err)
+	logger := zap.New(NewSentryCore(sentry.NewHub(s.client, sentry.NewScope()), SentryLogs(batcher, zapcore.InfoLevel)))
+
+	logger.Info("limited")
+	s.Require().True(batcher.flush(time.Second))
+	s.Len(s.items(<-s.envelopes), 1)
+
+	logger.Info("dropped")
+	s.Require().True(batcher.flush(time.Second))
+	s.Empty(s.envelopes, "logs should not be sent until Retry-After passes")
+	s.Equal(LogBatcherStats{Dropped: 2}, batcher.Stats())
+}
+
+func (s *SentryLogsSuite) TestInFlightLimit() {
+	release := make(chan struct{})
+	s.respond = func(http.ResponseWriter) {
+		<-release
+	}
+
+	batcher, err := NewLogBatcher(s.client, 1, time.Hour)
+	s.Require().NoError(err)
+	batcher.inFlight = make(chan struct{}, 1)
+	logger := zap.New(NewSentryCore(sentry.NewHub(s.client, sentry.NewScope()), SentryLogs(batcher, zapcore.InfoLevel)))
+
+	logger.Info("sent")
+	s.Len(s.items(<-s.envelopes), 1)
+	logger.Info("dropped")
+	close(release)
+
+	s.Require().True(batcher.flush(time.Second))
+	s.Empty(s.envelopes)
+	s.Equal(LogBatcherStats{Sent: 1, Dropped: 1}, batcher.Stats())
+}
+
+func (s *SentryLogsSuite) TestSendTimeout() {
+	release := make(chan struct{})
+	defer close(release)
+	s.respond = func(http.ResponseWriter) {
+		<-release
+	}
+
+	batcher, err := NewLogBatcher(s.client, 10, time.Hour)
+	s.Require().NoError(err)
+	batcher.sendTimeout = 10 * time.Millisecond
+	logger := zap.New(NewSentryCore(sentry.NewHub(s.client, sentry.NewScope()), SentryLogs(batcher, zapcore.InfoLevel)))
+
+	logger.Info("timed out")
+	s.True(batcher.flush(time.Second), "send should be cancelled after the timeout")
+	s.Len(s.items(<-s.envelopes), 1)
+	s.Equal(LogBatcherStats{Dropped: 1}, batcher.Stats())
+}
+
+func (s *SentryLogsSuite) TestNoDsn() {
+	client, err := sentry.NewClient(sentry.ClientOptions{})
+	s.Require().NoError(err)
+
+	_, err = NewLogBatcher(client, 10, time.Second)
+	s.ErrorIs(err, errNoDsn)
+}
+
+func TestSentryLogs(t *testing.T) {
+	suite.Run(t, new(SentryLogsSuite))
+}
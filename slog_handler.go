@@ -0,0 +1,204 @@
+//go:build go1.21
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SentryHandler is a slog.Handler which sends records to Sentry the same way SentryCore does.
+// If the context passed to the logger contains a logger using sentryCoreWrapper (e.g. one from ForkedLogger)
+// or a hub injected by RequestLogger records will be sent to that hub instead,
+// so zap and slog share one hub per request.
+type SentryHandler struct {
+	core *SentryCore
+
+	groups []string
+	// fields bound to the handler with namespaces for the first opened groups.
+	fields []zapcore.Field
+	opened int
+}
+
+// NewSentryHandler creates a slog.Handler backed by SentryCore created with provided hub and options.
+func NewSentryHandler(hub *sentry.Hub, options ...SentryCoreOption) slog.Handler {
+	return &SentryHandler{
+		core: NewSentryCore(hub, options...).(*SentryCore), //nolint:forcetypeassert
+	}
+}
+
+func (h *SentryHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(ZapLevel(level))
+}
+
+func (h *SentryHandler) Handle(ctx context.Context, record slog.Record) error {
+	ent := zapcore.Entry{
+		Level:   ZapLevel(record.Level),
+		Time:    record.Time,
+		Message: record.Message,
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+	}
+
+	core := h.core
+	fields := make([]zapcore.Field, 0, len(h.fields)+len(h.groups)+record.NumAttrs())
+	opened := 0
+	if ctxCore := h.contextCore(ctx); ctxCore != nil {
+		// Fields bound to this handler are passed along with the record because the context core
+		// has its own scope which is not aware of them.
+		core = ctxCore
+		fields = append(fields, h.fields...)
+		opened = h.opened
+	}
+
+	if !core.accepts(ent.Level) {
+		return nil
+	}
+
+	var attrFields []zapcore.Field
+	record.Attrs(func(attr slog.Attr) bool {
+		attrFields = appendSlogAttr(attrFields, attr)
+		return true
+	})
+	// groups which are not opened yet are omitted if the record has no attributes
+	if len(attrFields) != 0 {
+		fields = append(appendNamespaces(fields, h.groups[opened:]), attrFields...)
+	}
+
+	return core.Write(ent, fields)
+}
+
+// contextCore returns SentryCore for the hub associated with the context.
+// Returns nil if context has neither a logger with Sentry core nor a hub.
+func (h *SentryHandler) contextCore(ctx context.Context) *SentryCore {
+	if wrappedCore, ok := Ctx(ctx).Core().(sentryCoreWrapper); ok {
+		return wrappedCore.SentryCore()
+	}
+	if sentry.HasHubOnContext(ctx) {
+		core := *h.core
+		core.hub = sentry.GetHubFromContext(ctx)
+		return &core
+	}
+	return nil
+}
+
+func (h *SentryHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var attrFields []zapcore.Field
+	for _, attr := range attrs {
+		attrFields = appendSlogAttr(attrFields, attr)
+	}
+	// groups are opened only if there is at least one attribute to write in them
+	if len(attrFields) == 0 {
+		return h
+	}
+
+	scopeFields := appendNamespaces(make([]zapcore.Field, 0, len(h.groups)+len(attrFields)), h.groups)
+	scopeFields = append(scopeFields, attrFields...)
+	fields := append(appendNamespaces(h.fields[:len(h.fields):len(h.fields)], h.groups[h.opened:]), attrFields...)
+
+	return &SentryHandler{
+		core:   h.core.With(scopeFields).(*SentryCore), //nolint:forcetypeassert
+		groups: h.groups,
+		fields: fields,
+		opened: len(h.groups),
+	}
+}
+
+func (h *SentryHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &SentryHandler{
+		core:   h.core,
+		groups: append(h.groups[:len(h.groups):len(h.groups)], name),
+		fields: h.fields,
+		opened: h.opened,
+	}
+}
+
+// ZapLevel converts slog.Level to the closest zapcore.Level.
+func ZapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func appendNamespaces(fields []zapcore.Field, groups []string) []zapcore.Field {
+	for _, group := range groups {
+		fields = append(fields, zap.Namespace(group))
+	}
+	return fields
+}
+
+func appendSlogAttr(fields []zapcore.Field, attr slog.Attr) []zapcore.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	// Attributes of a group with an empty key are inlined in the parent
+	if attr.Value.Kind() == slog.KindGroup && attr.Key == "" {
+		for _, groupAttr := range attr.Value.Group() {
+			fields = appendSlogAttr(fields, groupAttr)
+		}
+		return fields
+	}
+
+	return append(fields, slogAttrToField(attr))
+}
+
+func slogAttrToField(attr slog.Attr) zapcore.Field {
+	switch attr.Value.Kind() {
+	case slog.KindBool:
+		return zap.Bool(attr.Key, attr.Value.Bool())
+	case slog.KindDuration:
+		return zap.Duration(attr.Key, attr.Value.Duration())
+	case slog.KindFloat64:
+		return zap.Float64(attr.Key, attr.Value.Float64())
+	case slog.KindInt64:
+		return zap.Int64(attr.Key, attr.Value.Int64())
+	case slog.KindString:
+		return zap.String(attr.Key, attr.Value.String())
+	case slog.KindTime:
+		return zap.Time(attr.Key, attr.Value.Time())
+	case slog.KindUint64:
+		return zap.Uint64(attr.Key, attr.Value.Uint64())
+	case slog.KindGroup:
+		return zap.Object(attr.Key, slogGroup(attr.Value.Group()))
+	case slog.KindAny, slog.KindLogValuer:
+		if err, ok := attr.Value.Any().(error); ok {
+			return zap.NamedError(attr.Key, err)
+		}
+		return zap.Any(attr.Key, attr.Value.Any())
+	default:
+		return zap.Any(attr.Key, attr.Value.Any())
+	}
+}
+
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	var fields []zapcore.Field
+	for _, attr := range g {
+		fields = appendSlogAttr(fields, attr)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+//go:build go1.22
+
+package logger
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSentryHandlerConformance(t *testing.T) {
+	var event *sentry.Event
+
+	slogtest.Run(t, func(t *testing.T) slog.Handler {
+		if strings.HasSuffix(t.Name(), "/zero-time") {
+			t.Skip("timestamp of Sentry event is set when the event is captured")
+		}
+
+		transportMock := NewMockTransport(gomock.NewController(t))
+		transportMock.EXPECT().Configure(gomock.Any()).Return()
+		transportMock.EXPECT().SendEvent(gomock.Any()).Do(func(e *sentry.Event) {
+			event = e
+		}).Return()
+		transportMock.EXPECT().Flush(gomock.Any()).Return(true).MinTimes(0)
+
+		client, err := sentry.NewClient(sentry.ClientOptions{Transport: transportMock})
+		require.NoError(t, err)
+		return NewSentryHandler(sentry.NewHub(client, sentry.NewScope()), EventLevel(zapcore.DebugLevel))
+	}, func(t *testing.T) map[string]any {
+		require.NotNil(t, event)
+
+		result := make(map[string]any, len(event.Extra)+3)
+		for key, value := range event.Extra {
+			result[key] = value
+		}
+		result[slog.TimeKey] = event.Timestamp
+		result[slog.LevelKey] = event.Level
+		result[slog.MessageKey] = event.Message
+		return result
+	})
+}
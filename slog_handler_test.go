@@ -0,0 +1,230 @@
+//go:build go1.21
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type SentryHandlerSuite struct {
+	suite.Suite
+
+	ctrl *gomock.Controller
+
+	hub           *sentry.Hub
+	sendEventMock func() *gomock.Call
+}
+
+func (suite *SentryHandlerSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+
+	transportMock := NewMockTransport(suite.ctrl)
+	transportMock.EXPECT().
+		Configure(gomock.AssignableToTypeOf(sentry.ClientOptions{})).
+		Return().
+		MinTimes(1)
+	suite.sendEventMock = func() *gomock.Call {
+		return transportMock.EXPECT().
+			SendEvent(gomock.AssignableToTypeOf(&sentry.Event{})).
+			Return()
+	}
+	transportMock.EXPECT().
+		Flush(gomock.Any()).
+		Return(true).
+		MinTimes(0)
+
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transportMock})
+	suite.Require().NoError(err)
+	suite.hub = sentry.NewHub(client, sentry.NewScope())
+}
+
+func (suite *SentryHandlerSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func (suite *SentryHandlerSuite) TestEnabled() {
+	handler := NewSentryHandler(suite.hub, BreadcrumbLevel(zapcore.InfoLevel))
+
+	suite.False(handler.Enabled(context.Background(), slog.LevelDebug))
+	suite.True(handler.Enabled(context.Background(), slog.LevelInfo))
+	suite.True(handler.Enabled(context.Background(), slog.LevelError))
+}
+
+func (suite *SentryHandlerSuite) TestBreadcrumbsAndEvents() {
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal("test event", event.Message)
+		suite.Equal(sentry.LevelError, event.Level)
+		suite.Equal(map[string]interface{}{
+			"global":    int64(1),
+			"event tag": int64(3),
+		}, event.Extra)
+
+		suite.Require().Len(event.Breadcrumbs, 1, "event should have one breadcrumb")
+		suite.Equal("breadcrumb", event.Breadcrumbs[0].Message)
+		suite.Equal(sentry.LevelInfo, event.Breadcrumbs[0].Level)
+		suite.Equal(map[string]interface{}{"tag": "value"}, event.Breadcrumbs[0].Data)
+	})
+
+	log := slog.New(NewSentryHandler(suite.hub)).With("global", 1)
+
+	log.Info("breadcrumb", "tag", "value")
+	log.Error("test event", "event tag", 3)
+}
+
+func (suite *SentryHandlerSuite) TestGroups() {
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal(map[string]interface{}{
+			"id": "42",
+			"request": map[string]interface{}{
+				"status": int64(500),
+			},
+		}, event.Extra)
+	})
+
+	log := slog.New(NewSentryHandler(suite.hub)).With("id", "42").WithGroup("request")
+	log.Error("test event", "status", 500)
+}
+
+func (suite *SentryHandlerSuite) TestInlineGroups() {
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal(map[string]interface{}{
+			"inline": true,
+			"group":  map[string]interface{}{"key": "value"},
+		}, event.Extra)
+	})
+
+	log := slog.New(NewSentryHandler(suite.hub))
+	log.Error("test event", slog.Group("", "inline", true), slog.Group("group", "key", "value"))
+}
+
+func (suite *SentryHandlerSuite) TestErrorsAndTags() {
+	userTags := SentryUserTagMap{ID: "username"}
+	log := slog.New(NewSentryHandler(suite.hub, UserTags(userTags), GenericTags("t1")))
+
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal("test_user", event.User.ID)
+		suite.Equal(map[string]string{"t1": strconv.Itoa(42)}, event.Tags)
+		suite.Empty(event.Extra)
+
+		suite.Require().Len(event.Exception, 1)
+		suite.Equal("*errors.fundamental", event.Exception[0].Type)
+		suite.Equal("error from pkg/errors", event.Exception[0].Value)
+		suite.NotNil(event.Exception[0].Stacktrace)
+	})
+	log.Error("error with exception",
+		"username", "test_user",
+		"t1", 42,
+		"error", errors.New("error from pkg/errors"),
+	)
+}
+
+func (suite *SentryHandlerSuite) TestUsesRequestLoggerHub() {
+	_ = sentry.Init(sentry.ClientOptions{Transport: suite.hub.Client().Transport})
+	defer func() { _ = sentry.Init(sentry.ClientOptions{}) }()
+
+	logger := zap.New(NewSentryCoreWrapper(zapcore.NewNopCore(), sentry.CurrentHub()))
+	log := slog.New(NewSentryHandler(sentry.CurrentHub()))
+
+	log.Info("should not be sent")
+
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal("slog error", event.Message)
+		suite.Equal(map[string]interface{}{"key": "value"}, event.Extra)
+
+		suite.Require().Len(event.Breadcrumbs, 2)
+		suite.Equal("zap breadcrumb", event.Breadcrumbs[0].Message)
+		suite.Equal("slog breadcrumb", event.Breadcrumbs[1].Message)
+		suite.Equal(map[string]interface{}{
+			"group": map[string]interface{}{"key": "value"},
+		}, event.Breadcrumbs[1].Data)
+	})
+
+	handler := RequestLogger(logger)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		Ctx(r.Context()).Debug("zap breadcrumb")
+		log.WithGroup("group").InfoContext(r.Context(), "slog breadcrumb", "key", "value")
+		log.With("key", "value").ErrorContext(r.Context(), "slog error")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil))
+
+	suite.hub.Flush(1 * time.Second)
+}
+
+func (suite *SentryHandlerSuite) TestBoundGroupsWithContextHub() {
+	suite.sendEventMock().Do(func(event *sentry.Event) {
+		suite.Equal(map[string]interface{}{
+			"a": map[string]interface{}{
+				"x": "1",
+				"b": map[string]interface{}{
+					"y": "2",
+					"z": "3",
+				},
+			},
+		}, event.Extra)
+	})
+
+	log := slog.New(NewSentryHandler(sentry.NewHub(nil, sentry.NewScope()))).
+		WithGroup("a").With("x", "1").
+		WithGroup("b").With("y", "2")
+
+	ctx := WithHub(context.Background(), suite.hub)
+	log.ErrorContext(ctx, "test event", "z", "3")
+}
+
+func Example_slog() {
+	// This will not work without SENTRY_DSN environment variable
+	_ = sentry.Init(sentry.ClientOptions{
+		Transport: sentry.NewHTTPSyncTransport(),
+	})
+
+	log := slog.New(NewSentryHandler(sentry.CurrentHub()))
+
+	log.Debug("this message will be logged as breadcrumb", "key", 1337)
+	log.Error("and this will create event in sentry", "error", errors.New("error from pkg/errors"))
+
+	// Inside handlers wrapped with RequestLogger pass request context so records will go to the request hub
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		log.ErrorContext(r.Context(), "this event will have breadcrumbs from zap and slog loggers")
+	})
+
+	_ = RequestLogger(zap.New(NewSentryCoreWrapper(NewCore(true), sentry.CurrentHub())))(handler)
+}
+
+func TestZapLevel(t *testing.T) {
+	tests := []struct {
+		arg  slog.Level
+		want zapcore.Level
+	}{
+		{slog.LevelDebug - 1, zapcore.DebugLevel},
+		{slog.LevelDebug, zapcore.DebugLevel},
+		{slog.LevelInfo, zapcore.InfoLevel},
+		{slog.LevelWarn, zapcore.WarnLevel},
+		{slog.LevelError, zapcore.ErrorLevel},
+		{slog.LevelError + 4, zapcore.ErrorLevel},
+	}
+
+	for _, tt := range tests {
+		//nolint:scopelint
+		t.Run(tt.arg.String(), func(t *testing.T) {
+			res := ZapLevel(tt.arg)
+			assert.Equal(t, tt.want, res, "ZapLevel() = %v, want %v", res, tt.want)
+		})
+	}
+}
+
+func TestSentryHandler(t *testing.T) {
+	suite.Run(t, new(SentryHandlerSuite))
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const defaultSpanLogLevel = zapcore.DebugLevel
+
+type startSpanConfig struct {
+	description   string
+	logLevel      zapcore.Level
+	sentryOptions []sentry.SpanOption
+}
+
+type StartSpanOption func(*startSpanConfig)
+
+// SpanDescription will set description of the span, it is also used as a message of the finish log entry.
+func SpanDescription(description string) StartSpanOption {
+	return func(c *startSpanConfig) {
+		c.description = description
+	}
+}
+
+// SpanLogLevel will set level of the entry logged when the span is finished.
+func SpanLogLevel(level zapcore.Level) StartSpanOption {
+	return func(c *startSpanConfig) {
+		c.logLevel = level
+	}
+}
+
+// SpanSentryOptions will pass options to sentry.StartSpan.
+func SpanSentryOptions(options ...sentry.SpanOption) StartSpanOption {
+	return func(c *startSpanConfig) {
+		c.sentryOptions = append(c.sentryOptions, options...)
+	}
+}
+
+// StartSpan starts a child span of the span from the context and returns a context with the span and
+// a logger bound to it (see CtxSpan). Returned function finishes the span, it should be called with
+// the error of the operation if any: span status is set to internal_error for non-nil error and to ok otherwise.
+// On finish a breadcrumb is added to the hub and duration of the span is logged.
+func StartSpan(ctx context.Context, op string, options ...StartSpanOption) (context.Context, func(err ...error)) {
+	config := &startSpanConfig{
+		logLevel: defaultSpanLogLevel,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	hub := Hub(ctx)
+	span := sentry.StartSpan(ctx, op, config.sentryOptions...)
+	span.Description = config.description
+	ctx = span.Context()
+
+	traceFields := defaultTraceFields
+	if parent, ok := ctx.Value(spanTraceCtxKey{}).(*spanTrace); ok {
+		traceFields = parent.fields
+	}
+	logger := withTraceFields(Ctx(ctx), traceFields.fields(span))
+	ctx = WithLogger(ctx, logger)
+	ctx = withSpanTrace(ctx, &spanTrace{span: span, fields: traceFields})
+
+	message := config.description
+	if message == "" {
+		message = op
+	}
+
+	start := time.Now()
+	return ctx, func(errs ...error) {
+		var err error
+		for _, e := range errs {
+			if e != nil {
+				err = e
+				break
+			}
+		}
+
+		if span.Status == sentry.SpanStatusUndefined {
+			if err != nil {
+				span.Status = sentry.SpanStatusInternalError
+			} else {
+				span.Status = sentry.SpanStatusOK
+			}
+		}
+		span.Finish()
+		duration := time.Since(start)
+
+		breadcrumb := sentry.Breadcrumb{
+			Category: op,
+			Data: map[string]interface{}{
+				"duration": duration.String(),
+				"status":   span.Status.String(),
+			},
+			Level:     sentry.LevelInfo,
+			Message:   message,
+			Timestamp: time.Now().UTC(),
+			Type:      BreadcrumbTypeDefault,
+		}
+		if err != nil {
+			breadcrumb.Level = sentry.LevelError
+			breadcrumb.Data["error"] = err.Error()
+		}
+		hub.AddBreadcrumb(&breadcrumb, nil)
+
+		// the breadcrumb is already added, so the entry is written to the local core only
+		if ce := logger.WithOptions(localOnly()).Check(config.logLevel, message); ce != nil {
+			fields := []zap.Field{zap.String("span.op", op), zap.Duration("duration", duration)}
+			if err != nil {
+				fields = append(fields, zap.Error(err))
+			}
+			ce.Write(fields...)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type StartSpanSuite struct {
+	suite.Suite
+
+	ctrl          *gomock.Controller
+	sendEventMock *gomock.Call
+
+	logs   *observer.ObservedLogs
+	logger *zap.Logger
+}
+
+func (s *StartSpanSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+
+	transportMock := NewMockTransport(s.ctrl)
+	transportMock.EXPECT().
+		Configure(gomock.AssignableToTypeOf(sentry.ClientOptions{})).
+		Return()
+	s.sendEventMock = transportMock.EXPECT().
+		SendEvent(gomock.AssignableToTypeOf(&sentry.Event{})).
+		Return().
+		MinTimes(0)
+	transportMock.EXPECT().
+		Flush(gomock.Any()).
+		Return(true).
+		MinTimes(0)
+
+	_ = sentry.Init(sentry.ClientOptions{
+		Transport: transportMock,
+	})
+
+	var core zapcore.Core
+	core, s.logs = observer.New(zapcore.DebugLevel)
+	s.logger = zap.New(NewSentryCoreWrapper(core, sentry.CurrentHub()))
+}
+
+func (s *StartSpanSuite) TearDownTest() {
+	s.ctrl.Finish()
+
+	// reset sentry client to default
+	_ = sentry.Init(sentry.ClientOptions{})
+}
+
+func (s *StartSpanSuite) TestStartSpan() {
+	s.sendEventMock.Do(func(event *sentry.Event) {
+		s.Require().Len(event.Breadcrumbs, 2, "span should be recorded with a single breadcrumb")
+
+		breadcrumb := event.Breadcrumbs[1]
+		s.Equal(BreadcrumbTypeDefault, breadcrumb.Type)
+		s.Equal("db.query", breadcrumb.Category)
+		s.Equal("SELECT 1", breadcrumb.Message)
+		s.Equal(sentry.LevelError, breadcrumb.Level)
+		s.Equal("internal_error", breadcrumb.Data["status"])
+		s.Equal("connection refused", breadcrumb.Data["error"])
+	}).Times(1)
+
+	var requestSpan, span *sentry.Span
+	handler := RequestLogger(s.logger)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		requestSpan = sentry.SpanFromContext(r.Context())
+
+		// fields bound by the handler should be kept by the span logger
+		ctx := WithLogger(r.Context(), Ctx(r.Context()).With(zap.String("user", "alice")))
+		ctx, finish := StartSpan(ctx, "db.query", SpanDescription("SELECT 1"), SpanLogLevel(zapcore.InfoLevel))
+		span = sentry.SpanFromContext(ctx)
+		Ctx(ctx).Debug("inside span")
+		finish(nil, errors.New("connection refused"))
+
+		Ctx(r.Context()).Error("query failed")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com/foo", nil))
+
+	s.Require().NotNil(span)
+	s.Equal(requestSpan.SpanID, span.ParentSpanID)
+	s.Equal(sentry.SpanStatusInternalError, span.Status)
+	s.Equal("SELECT 1", span.Description)
+
+	entries := s.logs.FilterMessage("inside span").All()
+	s.Require().Len(entries, 1)
+	s.Len(entries[0].Context, len(entries[0].ContextMap()), "fields should not be duplicated")
+	s.Equal(span.SpanID.String(), entries[0].ContextMap()["span_id"])
+	s.Equal("alice", entries[0].ContextMap()["user"])
+
+	entries = s.logs.FilterMessage("SELECT 1").All()
+	s.Require().Len(entries, 1)
+	s.Equal(zapcore.InfoLevel, entries[0].Level)
+	fields := entries[0].ContextMap()
+	s.Equal("db.query", fields["span.op"])
+	s.Equal("connection refused", fields["error"])
+	s.Contains(fields, "duration")
+	s.Equal(span.SpanID.String(), fields["span_id"])
+	s.Equal("alice", fields["user"])
+}
+
+func (s *StartSpanSuite) TestNestedSpans() {
+	ctx := WithLogger(context.Background(), s.logger)
+
+	ctx, finishParent := StartSpan(ctx, "job")
+	parent := sentry.SpanFromContext(ctx)
+	childCtx, finishChild := StartSpan(ctx, "job.step")
+	child := sentry.SpanFromContext(childCtx)
+	finishChild()
+	finishParent()
+
+	s.Equal(parent.SpanID, child.ParentSpanID)
+	s.Equal(sentry.SpanStatusOK, child.Status)
+	s.Equal(sentry.SpanStatusOK, parent.Status)
+
+	entries := s.logs.FilterMessage("job.step").All()
+	s.Require().Len(entries, 1)
+	s.Len(entries[0].Context, len(entries[0].ContextMap()), "fields should not be duplicated")
+	s.Equal(child.SpanID.String(), entries[0].ContextMap()["span_id"])
+	s.Equal(zapcore.DebugLevel, entries[0].Level)
+}
+
+func TestStartSpan(t *testing.T) {
+	suite.Run(t, new(StartSpanSuite))
+}
@@ -22,7 +22,8 @@ func filterFrames(stacktrace *sentry.Stacktrace) *sentry.Stacktrace {
 	filteredFrames := make([]sentry.Frame, 0, len(stacktrace.Frames))
 	for _, frame := range stacktrace.Frames {
 		if strings.HasPrefix(frame.Module, "go.uber.org/zap") ||
-			strings.HasPrefix(frame.Function, "go.uber.org/zap") {
+			strings.HasPrefix(frame.Function, "go.uber.org/zap") ||
+			frame.Module == "log/slog" {
 			break
 		}
 
@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// traceCore is a core with trace fields bound to it. It keeps the core without trace fields,
+// so trace fields could be replaced for a child span without losing fields bound after them.
+type traceCore struct {
+	zapcore.Core
+	base zapcore.Core
+}
+
+func newTraceCore(core zapcore.Core, fields []zapcore.Field) traceCore {
+	return traceCore{
+		Core: core.With(fields),
+		base: core,
+	}
+}
+
+func (c traceCore) With(fields []zapcore.Field) zapcore.Core {
+	return traceCore{
+		Core: c.Core.With(fields),
+		base: c.base.With(fields),
+	}
+}
+
+// withTraceFields returns the logger with trace fields replaced by provided ones, other bound fields are kept.
+// If the logger is a request logger, fields are added to the local core only.
+func withTraceFields(logger *zap.Logger, fields []zapcore.Field) *zap.Logger {
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		switch core := core.(type) {
+		case traceCore:
+			return newTraceCore(core.base, fields)
+		case sentryCoreWrapper:
+			if localCore, ok := core.LocalCore().(traceCore); ok {
+				return sentryCoreWrapper{newTraceCore(localCore.base, fields), core[1]}
+			}
+		}
+		return newTraceCore(core, fields)
+	}))
+}
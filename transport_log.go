@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	transportLogMessage     = "outgoing request"
+	transportBodyLogMessage = "outgoing response body read"
+)
+
+//nolint:gochecknoglobals
+var defaultTransportLogLevels = map[int]zapcore.Level{
+	4: zapcore.WarnLevel,
+	5: zapcore.ErrorLevel,
+}
+
+// TransportLogging will enable logging of outgoing requests with the logger from the request context.
+// Entries are written only to the local core of the logger, because Sentry already gets a breadcrumb for the request.
+// Entries are written when the response is received, with response size taken from Content-Length if it's known.
+// The number of bytes read by the caller is logged with debug level when the response body is read to the end
+// or closed.
+// By default 4xx responses are logged with warn level, 5xx responses and transport errors with error level
+// and other responses with debug level.
+func TransportLogging() BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.Log = true
+	}
+}
+
+// TransportLogLevel will set a level of log entries for responses with provided status class (e.g. 5 for 5xx responses).
+func TransportLogLevel(statusClass int, level zapcore.Level) BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.LogLevels[statusClass] = level
+	}
+}
+
+// TransportErrorLogLevel will set a level of log entries for requests failed without a response.
+func TransportErrorLogLevel(level zapcore.Level) BreadcrumbTransportOption {
+	return func(b *breadcrumbTransport) {
+		b.ErrorLogLevel = level
+	}
+}
+
+// logLevel returns level of the log entry for provided response or error.
+func (b breadcrumbTransport) logLevel(resp *http.Response, err error) zapcore.Level {
+	if err != nil {
+		return b.ErrorLogLevel
+	}
+	if level, ok := b.LogLevels[resp.StatusCode/100]; ok {
+		return level
+	}
+	return zapcore.DebugLevel
+}
+
+// logRequest writes the log entry about the request to the local core of the logger from the context.
+func (b breadcrumbTransport) logRequest(
+	ctx context.Context, req *http.Request, url string, resp *http.Response, err error, duration time.Duration,
+) {
+	ce := Ctx(ctx).WithOptions(localOnly()).Check(b.logLevel(resp, err), transportLogMessage)
+	if ce == nil {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.Duration("duration", duration),
+		zap.String("method", req.Method),
+		zap.String("host", req.URL.Host),
+		zap.String("url", url),
+	}
+	// zero length with a body means it is unknown
+	if req.ContentLength > 0 || req.Body == nil || req.Body == http.NoBody {
+		fields = append(fields, zap.Int64("request_size", req.ContentLength))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	} else {
+		fields = append(fields, zap.Int("status", resp.StatusCode))
+		if resp.ContentLength >= 0 {
+			fields = append(fields, zap.Int64("size", resp.ContentLength))
+		}
+	}
+	ce.Write(fields...)
+}
+
+// logResponseBody writes the log entry about the response body the caller is done with to the local core
+// of the logger from the context. Complete is false if the body was closed before it was read to the end.
+func (b breadcrumbTransport) logResponseBody(
+	ctx context.Context, req *http.Request, url string, read int64, complete bool,
+) {
+	ce := Ctx(ctx).WithOptions(localOnly()).Check(zapcore.DebugLevel, transportBodyLogMessage)
+	if ce == nil {
+		return
+	}
+
+	ce.Write(
+		zap.String("method", req.Method),
+		zap.String("host", req.URL.Host),
+		zap.String("url", url),
+		zap.Int64("size", read),
+		zap.Bool("complete", complete),
+	)
+}
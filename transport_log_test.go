@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestTransportLogging(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	transportMock := NewMockTransport(ctrl)
+	transportMock.EXPECT().Configure(gomock.Any()).Return()
+	// log entries should not be sent to Sentry even with error level
+	transportMock.EXPECT().SendEvent(gomock.Any()).Times(0)
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transportMock})
+	require.NoError(t, err)
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+		if r.URL.Query().Get("chunked") == "" {
+			w.Header().Set("Content-Length", "2")
+		}
+		w.WriteHeader(status)
+		if r.URL.Query().Get("chunked") != "" {
+			// flushed headers without Content-Length make the response chunked
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := WithHub(context.Background(), hub)
+	ctx = WithLogger(ctx, zap.New(NewSentryCoreWrapper(core, hub)).With(zap.String("request_id", "id")))
+
+	do := func(transport http.RoundTripper, status int) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"?status="+strconv.Itoa(status), nil)
+		require.NoError(t, err)
+
+		resp, err := (&http.Client{Transport: transport}).Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}
+
+	t.Run("sizes", func(t *testing.T) {
+		transport := NewBreadcrumbTransport(sentry.LevelDebug, nil, TransportLogging())
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.URL+"?status=200&chunked=1",
+			strings.NewReader("request"))
+		require.NoError(t, err)
+		resp, err := (&http.Client{Transport: transport}).Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, int64(-1), resp.ContentLength)
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1, "entry should be written before the body is read")
+		fields := entries[0].ContextMap()
+		assert.Equal(t, int64(7), fields["request_size"])
+		assert.NotContains(t, fields, "size", "size of chunked response isn't known")
+
+		_, err = io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		entries = logs.TakeAll()
+		require.Len(t, entries, 1)
+		assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+		assert.Equal(t, "outgoing response body read", entries[0].Message)
+		fields = entries[0].ContextMap()
+		assert.Equal(t, ts.URL+"?status=200&chunked=1", fields["url"])
+		assert.Equal(t, int64(2), fields["size"], "bytes read by the caller should be counted")
+		assert.Equal(t, true, fields["complete"])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		do(NewBreadcrumbTransport(sentry.LevelDebug, nil), http.StatusOK)
+		assert.Zero(t, logs.TakeAll())
+	})
+
+	t.Run("default levels", func(t *testing.T) {
+		transport := NewBreadcrumbTransport(sentry.LevelDebug, nil, TransportLogging())
+		for _, status := range []int{http.StatusOK, http.StatusNotFound, http.StatusBadGateway} {
+			do(transport, status)
+		}
+		do(NewBreadcrumbTransport(sentry.LevelDebug, failingTransport{}, TransportLogging()), http.StatusOK)
+
+		entries := logs.FilterMessage("outgoing request").All()
+		require.Len(t, entries, 4)
+		for i, level := range []zapcore.Level{zapcore.DebugLevel, zapcore.WarnLevel, zapcore.ErrorLevel, zapcore.ErrorLevel} {
+			assert.Equal(t, level, entries[i].Level)
+			assert.Equal(t, "outgoing request", entries[i].Message)
+		}
+
+		fields := entries[1].ContextMap()
+		assert.Equal(t, "id", fields["request_id"])
+		assert.Equal(t, "GET", fields["method"])
+		assert.Equal(t, ts.Listener.Addr().String(), fields["host"])
+		assert.Equal(t, ts.URL+"?status=404", fields["url"])
+		assert.Equal(t, int64(404), fields["status"])
+		assert.Equal(t, int64(0), fields["request_size"])
+		assert.Equal(t, int64(2), fields["size"])
+		assert.Contains(t, fields, "duration")
+
+		fields = entries[3].ContextMap()
+		assert.Contains(t, fields["error"], "connection refused")
+		assert.NotContains(t, fields, "status")
+
+		entries = logs.FilterMessage("outgoing response body read").All()
+		require.Len(t, entries, 3, "closed bodies should be logged")
+		fields = entries[0].ContextMap()
+		assert.Equal(t, int64(0), fields["size"])
+		assert.Equal(t, false, fields["complete"])
+		logs.TakeAll()
+	})
+
+	t.Run("custom levels", func(t *testing.T) {
+		transport := NewBreadcrumbTransport(sentry.LevelDebug, nil, TransportLogging(),
+			TransportLogLevel(2, zapcore.InfoLevel),
+			TransportLogLevel(5, zapcore.WarnLevel),
+		)
+		do(transport, http.StatusOK)
+		do(transport, http.StatusServiceUnavailable)
+		do(NewBreadcrumbTransport(sentry.LevelDebug, failingTransport{},
+			TransportLogging(), TransportErrorLogLevel(zapcore.WarnLevel),
+		), http.StatusOK)
+
+		entries := logs.FilterMessage("outgoing request").All()
+		require.Len(t, entries, 3)
+		assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+		assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+		assert.Equal(t, zapcore.WarnLevel, entries[2].Level)
+	})
+}